@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	goinsightecho "github.com/NathanSanchezDev/go-insight-go-sdk/goinsight/echo"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -26,7 +27,7 @@ func main() {
 	e.Use(middleware.Recover())
 
 	// Add Go-Insight auto-instrumentation
-	e.Use(client.EchoMiddleware())
+	e.Use(goinsightecho.Middleware(client))
 
 	// Routes with automatic instrumentation
 	e.GET("/users", func(c echo.Context) error {
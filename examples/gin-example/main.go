@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	goinsightgin "github.com/NathanSanchezDev/go-insight-go-sdk/goinsight/gin"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,7 +20,7 @@ func main() {
 
 	// Setup Gin with auto-instrumentation
 	r := gin.Default()
-	r.Use(client.GinMiddleware())
+	r.Use(goinsightgin.Middleware(client))
 
 	// Your routes are automatically instrumented
 	r.GET("/users", func(c *gin.Context) {
@@ -28,9 +28,9 @@ func (s *UserService) ProcessUser(ctx context.Context, userID string) error {
 	}
 	defer s.client.FinishSpan(spanCtx)
 
-	s.client.LogInfo(spanCtx, "Starting user processing", map[string]interface{}{
-		"user_id": userID,
-	})
+	// Bind the user once instead of repeating it in every log
+	log := s.client.Logger().Named("users").With("user_id", userID)
+	log.Info(spanCtx, "Starting user processing")
 
 	// Step 1: Validate user
 	if err := s.validateUser(spanCtx, userID); err != nil {
@@ -53,10 +53,7 @@ func (s *UserService) ProcessUser(ctx context.Context, userID string) error {
 		return err
 	}
 
-	s.client.LogInfo(spanCtx, "User processing completed successfully", map[string]interface{}{
-		"user_id": userID,
-		"steps":   4,
-	})
+	log.Info(spanCtx, "User processing completed successfully", "steps", 4)
 
 	return nil
 }
@@ -0,0 +1,73 @@
+// Package goinsightamqp propagates Go-Insight traces through amqp091-go
+// message headers
+package goinsightamqp
+
+import (
+	"context"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// TableCarrier adapts an amqp.Table to goinsight.Carrier. Only string header
+// values are read.
+type TableCarrier amqp.Table
+
+var _ goinsight.Carrier = TableCarrier{}
+
+// Get returns the header named key when it holds a string
+func (t TableCarrier) Get(key string) string {
+	if v, ok := t[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Set stores value under key
+func (t TableCarrier) Set(key, value string) {
+	t[key] = value
+}
+
+// Publish publishes msg with ch.PublishWithContext, adding the trace
+// context of ctx to a copy of its header table, and records an "amqp.send"
+// span for the exchange and routing key when ctx carries a trace
+func Publish(ctx context.Context, client *goinsight.Client, ch *amqp.Channel, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	spanCtx, finish := client.StartProducerSpan(ctx, "amqp.send", map[string]interface{}{
+		"messaging.system":               "rabbitmq",
+		"messaging.operation":            "send",
+		"messaging.destination":          exchange,
+		"messaging.rabbitmq.routing_key": key,
+	})
+
+	headers := make(amqp.Table, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	goinsight.Inject(spanCtx, TableCarrier(headers))
+	msg.Headers = headers
+
+	err := ch.PublishWithContext(ctx, exchange, key, mandatory, immediate, msg)
+	finish(err)
+	return err
+}
+
+// Process runs fn for a delivery inside an "amqp.process" span recording the
+// exchange and routing key it arrived through. Ack or nack d after Process
+// returns, depending on its error.
+//
+//	for d := range deliveries {
+//		err := goinsightamqp.Process(ctx, client, d, handle)
+//	}
+func Process(ctx context.Context, client *goinsight.Client, d amqp.Delivery, fn func(ctx context.Context) error) error {
+	carrier := TableCarrier(d.Headers)
+	if carrier == nil {
+		carrier = TableCarrier{}
+	}
+
+	return client.ProcessMessage(ctx, carrier, "amqp.process", map[string]interface{}{
+		"messaging.system":               "rabbitmq",
+		"messaging.operation":            "process",
+		"messaging.destination":          d.Exchange,
+		"messaging.rabbitmq.routing_key": d.RoutingKey,
+	}, fn)
+}
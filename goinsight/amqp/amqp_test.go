@@ -0,0 +1,29 @@
+package goinsightamqp
+
+import (
+	"testing"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestTableCarrierSetGet(t *testing.T) {
+	table := amqp.Table{"retries": int32(3), "tenant": "acme"}
+	carrier := TableCarrier(table)
+
+	carrier.Set(goinsight.TraceIDHeader, "trace-1")
+	carrier.Set(goinsight.TraceIDHeader, "trace-2")
+	if got := carrier.Get(goinsight.TraceIDHeader); got != "trace-2" {
+		t.Errorf("Get after overwrite = %q", got)
+	}
+	if got := carrier.Get("tenant"); got != "acme" {
+		t.Errorf("existing header = %q", got)
+	}
+	// Only string values are read
+	if got := carrier.Get("retries"); got != "" {
+		t.Errorf("non-string header = %q", got)
+	}
+	if len(table) != 3 {
+		t.Errorf("table has %d headers, want 3", len(table))
+	}
+}
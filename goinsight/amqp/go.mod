@@ -0,0 +1,7 @@
+module github.com/NathanSanchezDev/go-insight-go-sdk/goinsight/amqp
+
+go 1.21
+
+require github.com/NathanSanchezDev/go-insight-go-sdk v0.2.0
+
+require github.com/rabbitmq/amqp091-go v1.9.0
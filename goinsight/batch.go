@@ -0,0 +1,133 @@
+package goinsight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// batcher exports queued logs and metrics from a background goroutine. A
+// batch is a flush trigger: its exports still run one at a time.
+type batcher struct {
+	queue    chan func(ctx context.Context) error
+	size     int
+	interval time.Duration
+
+	flush chan chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newBatcher(size int, interval time.Duration, queueSize int) *batcher {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+
+	b := &batcher{
+		queue:    make(chan func(ctx context.Context) error, queueSize),
+		size:     size,
+		interval: interval,
+		flush:    make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// enqueue queues an export without blocking, failing with ErrQueueFull when
+// the queue has no room
+func (b *batcher) enqueue(export func(ctx context.Context) error) error {
+	select {
+	case b.queue <- export:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]func(ctx context.Context) error, 0, b.size)
+	send := func() {
+		for i, export := range batch {
+			export(context.Background())
+			batch[i] = nil
+		}
+		batch = batch[:0]
+	}
+	// drain sends everything queued so far
+	drain := func() {
+		for {
+			select {
+			case item := <-b.queue:
+				batch = append(batch, item)
+				if len(batch) == b.size {
+					send()
+				}
+			default:
+				send()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case item := <-b.queue:
+			batch = append(batch, item)
+			if len(batch) == b.size {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-b.flush:
+			drain()
+			close(ack)
+		case <-b.stop:
+			drain()
+			return
+		}
+	}
+}
+
+// flushQueue blocks until everything queued before the call has been sent,
+// or ctx is done
+func (b *batcher) flushQueue(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case b.flush <- ack:
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdown sends what is queued and stops the goroutine, waiting until ctx
+// is done at most
+func (b *batcher) shutdown(ctx context.Context) error {
+	b.once.Do(func() { close(b.stop) })
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,266 @@
+package goinsight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of the circuit breaker around the exporter
+type CircuitState string
+
+// Circuit breaker states
+const (
+	// CircuitClosed passes every call to Go-Insight
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen fails every call with ErrCircuitOpen without sending it
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen lets one call through to probe whether Go-Insight
+	// has recovered
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// Default circuit breaker settings
+const (
+	DefaultBreakerErrorRate   = 0.5
+	DefaultBreakerMinRequests = 20
+	DefaultBreakerWindow      = 10 * time.Second
+	DefaultBreakerOpenTimeout = 30 * time.Second
+)
+
+// circuitBreaker opens when too many calls in a window fail or are slow,
+// fails calls fast while open, and probes with a single call once
+// openTimeout has passed
+type circuitBreaker struct {
+	errorRate   float64
+	minRequests int
+	window      time.Duration
+	slowCall    time.Duration
+	openTimeout time.Duration
+
+	mu          sync.Mutex
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+
+	// generation changes with every state change, so calls that outlive
+	// the state they were allowed in aren't counted against the next one
+	generation uint64
+}
+
+func newCircuitBreaker(config Config) *circuitBreaker {
+	b := &circuitBreaker{
+		errorRate:   config.BreakerErrorRate,
+		minRequests: config.BreakerMinRequests,
+		window:      config.BreakerWindow,
+		slowCall:    config.BreakerSlowCall,
+		openTimeout: config.BreakerOpenTimeout,
+		state:       CircuitClosed,
+	}
+	if b.errorRate <= 0 {
+		b.errorRate = DefaultBreakerErrorRate
+	}
+	if b.minRequests <= 0 {
+		b.minRequests = DefaultBreakerMinRequests
+	}
+	if b.window <= 0 {
+		b.window = DefaultBreakerWindow
+	}
+	if b.openTimeout <= 0 {
+		b.openTimeout = DefaultBreakerOpenTimeout
+	}
+	return b
+}
+
+// State returns the breaker's state, CircuitClosed for a nil breaker
+func (b *circuitBreaker) State() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.openTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a call may be sent at now, and the generation to
+// pass to done with its outcome
+func (b *circuitBreaker) allow(now time.Time) (generation uint64, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.openTimeout {
+			return 0, false
+		}
+		b.setState(CircuitHalfOpen)
+		b.probing = false
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probing {
+			return 0, false
+		}
+		b.probing = true
+	}
+	return b.generation, true
+}
+
+// done records the outcome of a call allowed in generation that took d.
+// Calls from an earlier generation, such as a slow one sent while closed
+// that returns during the half-open probe, are ignored. So are calls the
+// caller gave up on and errors that don't point at the backend, such as a
+// rejected API key.
+func (b *circuitBreaker) done(ctx context.Context, generation uint64, now time.Time, d time.Duration, err error) {
+	failed, counted := breakerOutcome(ctx, err)
+	if b.slowCall > 0 && d >= b.slowCall {
+		failed, counted = true, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	if b.state == CircuitHalfOpen {
+		b.probing = false
+		if !counted {
+			return
+		}
+		if failed {
+			b.open(now)
+			return
+		}
+		b.setState(CircuitClosed)
+		b.windowStart, b.requests, b.failures = now, 0, 0
+		return
+	}
+	if !counted {
+		return
+	}
+
+	if now.Sub(b.windowStart) >= b.window {
+		b.windowStart, b.requests, b.failures = now, 0, 0
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= b.minRequests && float64(b.failures) >= b.errorRate*float64(b.requests) {
+		b.open(now)
+	}
+}
+
+func (b *circuitBreaker) open(now time.Time) {
+	b.setState(CircuitOpen)
+	b.openedAt = now
+	b.probing = false
+}
+
+func (b *circuitBreaker) setState(state CircuitState) {
+	b.state = state
+	b.generation++
+}
+
+// breakerOutcome reports whether err is a failure of the backend, and
+// whether the call counts at all
+func breakerOutcome(ctx context.Context, err error) (failed, counted bool) {
+	if err == nil {
+		return false, true
+	}
+	if ctx.Err() != nil {
+		return false, false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		// Server errors, throttling and timeouts, like retries
+		return apiErr.Retryable, apiErr.Retryable
+	}
+	// Network failures, timeouts and unusable responses
+	return true, true
+}
+
+// breakerExporter fails calls with ErrCircuitOpen while its breaker is open
+type breakerExporter struct {
+	next    Exporter
+	breaker *circuitBreaker
+	stats   *clientStats
+}
+
+var _ Exporter = (*breakerExporter)(nil)
+
+func (e *breakerExporter) call(ctx context.Context, export func() error) error {
+	start := time.Now()
+	generation, ok := e.breaker.allow(start)
+	if !ok {
+		e.stats.drop()
+		return ErrCircuitOpen
+	}
+	err := export()
+	e.breaker.done(ctx, generation, time.Now(), time.Since(start), err)
+	return err
+}
+
+func (e *breakerExporter) ExportLog(ctx context.Context, entry LogEntry) error {
+	return e.call(ctx, func() error {
+		return e.next.ExportLog(ctx, entry)
+	})
+}
+
+func (e *breakerExporter) ExportMetric(ctx context.Context, metric Metric) error {
+	return e.call(ctx, func() error {
+		return e.next.ExportMetric(ctx, metric)
+	})
+}
+
+func (e *breakerExporter) StartTrace(ctx context.Context, trace Trace) (string, error) {
+	var id string
+	err := e.call(ctx, func() (err error) {
+		id, err = e.next.StartTrace(ctx, trace)
+		return err
+	})
+	return id, err
+}
+
+func (e *breakerExporter) StartSpan(ctx context.Context, span Span) (string, error) {
+	var id string
+	err := e.call(ctx, func() (err error) {
+		id, err = e.next.StartSpan(ctx, span)
+		return err
+	})
+	return id, err
+}
+
+func (e *breakerExporter) EndSpan(ctx context.Context, spanID string, end SpanEnd) error {
+	return e.call(ctx, func() error {
+		return e.next.EndSpan(ctx, spanID, end)
+	})
+}
+
+func (e *breakerExporter) EndTrace(ctx context.Context, traceID string) error {
+	return e.call(ctx, func() error {
+		return e.next.EndTrace(ctx, traceID)
+	})
+}
+
+func (e *breakerExporter) ExportError(ctx context.Context, event ErrorEvent) error {
+	return e.call(ctx, func() error {
+		return e.next.ExportError(ctx, event)
+	})
+}
+
+func (e *breakerExporter) Shutdown(ctx context.Context) error {
+	return e.next.Shutdown(ctx)
+}
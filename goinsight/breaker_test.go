@@ -0,0 +1,73 @@
+package goinsight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerIgnoresStaleOutcomes(t *testing.T) {
+	b := newCircuitBreaker(Config{BreakerMinRequests: 2, BreakerOpenTimeout: time.Second})
+	ctx := context.Background()
+	errDown := errors.New("connection refused")
+	start := time.Now()
+
+	// A slow call sent while closed, still in flight when the breaker opens
+	slow, ok := b.allow(start)
+	if !ok {
+		t.Fatal("closed breaker refused a call")
+	}
+	for i := 0; i < 2; i++ {
+		gen, _ := b.allow(start)
+		b.done(ctx, gen, start, 0, errDown)
+	}
+	if state := b.State(); state != CircuitOpen {
+		t.Fatalf("state %q after failures, want open", state)
+	}
+
+	probeTime := start.Add(2 * time.Second)
+	probe, ok := b.allow(probeTime)
+	if !ok {
+		t.Fatal("breaker refused the probe after the open timeout")
+	}
+
+	// The slow call succeeding must not close the breaker or end the probe
+	b.done(ctx, slow, probeTime, 0, nil)
+	if b.state != CircuitHalfOpen {
+		t.Fatalf("state %q after a stale success, want half-open", b.state)
+	}
+	if _, ok := b.allow(probeTime); ok {
+		t.Fatal("a second probe was allowed while the first is in flight")
+	}
+
+	b.done(ctx, probe, probeTime, 0, errDown)
+	if b.state != CircuitOpen {
+		t.Fatalf("state %q after the probe failed, want open", b.state)
+	}
+}
+
+func TestCircuitBreakerProbeCloses(t *testing.T) {
+	b := newCircuitBreaker(Config{BreakerMinRequests: 1, BreakerOpenTimeout: time.Second})
+	ctx := context.Background()
+	start := time.Now()
+
+	gen, _ := b.allow(start)
+	b.done(ctx, gen, start, 0, errors.New("timeout"))
+	if _, ok := b.allow(start); ok {
+		t.Fatal("open breaker allowed a call")
+	}
+
+	probeTime := start.Add(time.Second)
+	probe, ok := b.allow(probeTime)
+	if !ok {
+		t.Fatal("breaker refused the probe")
+	}
+	b.done(ctx, probe, probeTime, 0, nil)
+	if b.state != CircuitClosed {
+		t.Fatalf("state %q after a successful probe, want closed", b.state)
+	}
+	if _, ok := b.allow(probeTime); !ok {
+		t.Fatal("closed breaker refused a call")
+	}
+}
@@ -0,0 +1,256 @@
+package goinsight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ErrorEvent reports occurrences of one error, grouped by fingerprint
+type ErrorEvent struct {
+	ServiceName string            `json:"service_name"`
+	Fingerprint string            `json:"fingerprint"`
+	Type        string            `json:"type"`
+	Message     string            `json:"message"`
+	Chain       []ErrorCause      `json:"chain,omitempty"`
+	Stacktrace  []StackFrame      `json:"stacktrace,omitempty"`
+	Count       int               `json:"count"`
+	FirstSeen   time.Time         `json:"first_seen"`
+	LastSeen    time.Time         `json:"last_seen"`
+	TraceID     string            `json:"trace_id,omitempty"`
+	SpanID      string            `json:"span_id,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Resource    Resource          `json:"resource,omitempty"`
+}
+
+// logEntry renders the event as an error log, for servers without the
+// /errors endpoint
+func (e ErrorEvent) logEntry() LogEntry {
+	metadata := map[string]interface{}{
+		ErrorTypeKey:        e.Type,
+		ErrorChainKey:       e.Chain,
+		StacktraceKey:       e.Stacktrace,
+		ErrorFingerprintKey: e.Fingerprint,
+		"error_count":       e.Count,
+		"first_seen":        e.FirstSeen,
+	}
+	if len(e.Tags) > 0 {
+		metadata["tags"] = e.Tags
+	}
+	return LogEntry{
+		ServiceName: e.ServiceName,
+		LogLevel:    "ERROR",
+		Message:     e.Message,
+		Timestamp:   e.LastSeen,
+		TraceID:     e.TraceID,
+		SpanID:      e.SpanID,
+		Metadata:    metadata,
+		Resource:    e.Resource,
+	}
+}
+
+// CaptureOption configures an error passed to CaptureError
+type CaptureOption func(*captureConfig)
+
+type captureConfig struct {
+	tags        map[string]string
+	fingerprint string
+}
+
+// WithTags attaches tags to the error event. Repeated options are merged.
+func WithTags(tags map[string]string) CaptureOption {
+	return func(cfg *captureConfig) {
+		if cfg.tags == nil {
+			cfg.tags = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			cfg.tags[k] = v
+		}
+	}
+}
+
+// WithFingerprint groups the error under fingerprint instead of the one
+// computed from its types and stack
+func WithFingerprint(fingerprint string) CaptureOption {
+	return func(cfg *captureConfig) {
+		cfg.fingerprint = fingerprint
+	}
+}
+
+// CaptureError reports err as an error event. The first occurrence of a
+// fingerprint is sent right away; repeats within Config.ErrorWindow are
+// counted locally and sent as one event when the window ends, so a bug
+// firing thousands of times a minute costs one request per window. The
+// event links to the trace in ctx, if any. A nil err is ignored.
+func (c *Client) CaptureError(ctx context.Context, err error, opts ...CaptureOption) error {
+	if err == nil {
+		return nil
+	}
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+
+	cfg := &captureConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	stack := ErrorStack(err)
+	if stack == nil {
+		stack = callerStack()
+	}
+	fingerprint := cfg.fingerprint
+	if fingerprint == "" {
+		fingerprint = ErrorFingerprint(err, stack)
+	}
+
+	event := ErrorEvent{
+		ServiceName: c.serviceName,
+		Fingerprint: fingerprint,
+		Type:        errorType(err),
+		Message:     c.redact.RedactString(err.Error()),
+		Chain:       ErrorChain(err),
+		Stacktrace:  stack,
+		Release:     c.resource[AttrServiceVersion],
+		Environment: c.resource[AttrEnvironment],
+		Tags:        cfg.tags,
+		Resource:    c.resource,
+	}
+	for i := range event.Chain {
+		event.Chain[i].Message = c.redact.RedactString(event.Chain[i].Message)
+	}
+	if c.redact != nil && event.Tags != nil {
+		event.Tags = c.redact.redactValue(event.Tags).(map[string]string)
+	}
+	if traceCtx := GetTraceFromContext(ctx); traceCtx != nil {
+		event.TraceID = traceCtx.TraceID
+		event.SpanID = traceCtx.SpanID
+	}
+
+	first, ok := c.captured.record(event, time.Now())
+	if !ok {
+		return nil
+	}
+	return c.sendError(ctx, first)
+}
+
+// errorAggregator counts repeated error events per fingerprint and sends
+// the counts once per window
+type errorAggregator struct {
+	window time.Duration
+	send   func(ErrorEvent)
+
+	mu     sync.Mutex
+	groups map[string]*errorGroup
+	start  sync.Once
+	once   sync.Once
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// errorGroup holds the latest occurrence of a fingerprint and how many
+// occurrences haven't been sent yet
+type errorGroup struct {
+	event   ErrorEvent
+	pending int
+}
+
+func newErrorAggregator(window time.Duration, send func(ErrorEvent)) *errorAggregator {
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &errorAggregator{
+		window: window,
+		send:   send,
+		groups: make(map[string]*errorGroup),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// record adds an occurrence at now. It returns the event to send right away
+// when this is the first occurrence of its fingerprint in the current
+// burst.
+func (a *errorAggregator) record(event ErrorEvent, now time.Time) (ErrorEvent, bool) {
+	a.start.Do(func() { go a.run() })
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	g, ok := a.groups[event.Fingerprint]
+	if !ok {
+		event.Count = 1
+		event.FirstSeen = now
+		event.LastSeen = now
+		a.groups[event.Fingerprint] = &errorGroup{event: event}
+		return event, true
+	}
+
+	firstSeen := g.event.FirstSeen
+	g.event = event
+	g.event.FirstSeen = firstSeen
+	g.event.LastSeen = now
+	g.pending++
+	return ErrorEvent{}, false
+}
+
+func (a *errorAggregator) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			a.flush(now)
+		case <-a.stop:
+			a.flush(time.Now())
+			return
+		}
+	}
+}
+
+// flush sends the pending counts. Groups quiet for a whole window are
+// forgotten, so their next occurrence is sent right away again.
+func (a *errorAggregator) flush(now time.Time) {
+	var events []ErrorEvent
+
+	a.mu.Lock()
+	for fingerprint, g := range a.groups {
+		if g.pending > 0 {
+			event := g.event
+			event.Count = g.pending
+			events = append(events, event)
+			g.pending = 0
+			continue
+		}
+		if now.Sub(g.event.LastSeen) >= a.window {
+			delete(a.groups, fingerprint)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, event := range events {
+		a.send(event)
+	}
+}
+
+// shutdown sends the pending counts, waiting until ctx is done at most
+func (a *errorAggregator) shutdown(ctx context.Context) error {
+	started := true
+	a.start.Do(func() { started = false })
+	if !started {
+		return nil
+	}
+	a.once.Do(func() { close(a.stop) })
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
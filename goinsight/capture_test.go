@@ -0,0 +1,143 @@
+package goinsight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestErrorAggregatorWindow(t *testing.T) {
+	var sent []ErrorEvent
+	agg := newErrorAggregator(time.Minute, func(event ErrorEvent) {
+		sent = append(sent, event)
+	})
+	defer agg.shutdown(context.Background())
+
+	start := time.Now()
+	event := ErrorEvent{Fingerprint: "abc", Message: "timeout"}
+
+	first, ok := agg.record(event, start)
+	if !ok || first.Count != 1 || !first.FirstSeen.Equal(start) {
+		t.Fatalf("first occurrence = %+v, %v; want it sent with count 1", first, ok)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, ok := agg.record(event, start.Add(time.Duration(i)*time.Second)); ok {
+			t.Fatalf("repeat %d was sent right away", i)
+		}
+	}
+	other, ok := agg.record(ErrorEvent{Fingerprint: "def"}, start)
+	if !ok || other.Count != 1 {
+		t.Fatalf("another fingerprint wasn't sent right away")
+	}
+
+	agg.flush(start.Add(time.Minute))
+	if len(sent) != 1 {
+		t.Fatalf("flush sent %d events, want 1", len(sent))
+	}
+	if got := sent[0]; got.Fingerprint != "abc" || got.Count != 3 || !got.FirstSeen.Equal(start) || !got.LastSeen.Equal(start.Add(3*time.Second)) {
+		t.Errorf("aggregated event = count %d, first %v, last %v", got.Count, got.FirstSeen, got.LastSeen)
+	}
+
+	// A repeat within the next window is held back again
+	if _, ok := agg.record(event, start.Add(90*time.Second)); ok {
+		t.Error("a repeat after a flush was sent right away")
+	}
+	agg.flush(start.Add(2 * time.Minute))
+	if len(sent) != 2 || sent[1].Count != 1 {
+		t.Fatalf("second window sent %d events", len(sent)-1)
+	}
+
+	// A fingerprint quiet for a whole window is forgotten and starts over
+	agg.flush(start.Add(4 * time.Minute))
+	if len(sent) != 2 {
+		t.Fatalf("a flush without repeats sent %d events", len(sent)-2)
+	}
+	if again, ok := agg.record(event, start.Add(5*time.Minute)); !ok || !again.FirstSeen.Equal(start.Add(5*time.Minute)) {
+		t.Error("a fingerprint quiet for a whole window wasn't sent right away")
+	}
+}
+
+func TestCaptureErrorAggregates(t *testing.T) {
+	exporter := &recordingExporter{}
+	client, err := NewClient(WithServiceName("capture-test"), WithExporter(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errTimeout := errors.New("gateway timeout")
+	for i := 0; i < 5; i++ {
+		if err := client.CaptureError(context.Background(), errTimeout); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client.CaptureError(context.Background(), errors.New("other"), WithFingerprint("custom"))
+
+	exporter.mu.Lock()
+	sent := len(exporter.events)
+	exporter.mu.Unlock()
+	if sent != 2 {
+		t.Fatalf("sent %d events before the window ended, want 2", sent)
+	}
+
+	// Close sends the pending count
+	client.Close()
+	if len(exporter.events) != 3 {
+		t.Fatalf("sent %d events in total, want 3", len(exporter.events))
+	}
+	first, custom, repeats := exporter.events[0], exporter.events[1], exporter.events[2]
+	if first.Count != 1 || repeats.Count != 4 || repeats.Fingerprint != first.Fingerprint {
+		t.Errorf("counts %d then %d for fingerprints %q and %q", first.Count, repeats.Count, first.Fingerprint, repeats.Fingerprint)
+	}
+	if custom.Fingerprint != "custom" {
+		t.Errorf("fingerprint %q, want custom", custom.Fingerprint)
+	}
+}
+
+func TestExportErrorFallsBackToLog(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		errorsCalls int
+		logs        []LogEntry
+	)
+	client := newHTTPClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/errors":
+			errorsCalls++
+			http.NotFound(w, r)
+		case "/logs":
+			var entry LogEntry
+			json.NewDecoder(r.Body).Decode(&entry)
+			logs = append(logs, entry)
+		}
+	}))
+
+	ctx := context.Background()
+	if err := client.CaptureError(ctx, errors.New("gateway timeout"), WithTags(map[string]string{"gateway": "stripe"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CaptureError(ctx, errors.New("other"), WithFingerprint("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if errorsCalls != 1 {
+		t.Errorf("/errors was called %d times, want once", errorsCalls)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("got %d logs, want 2", len(logs))
+	}
+	entry := logs[0]
+	if entry.LogLevel != "ERROR" || entry.Message != "gateway timeout" || entry.Metadata[ErrorFingerprintKey] == "" {
+		t.Errorf("log = %+v", entry)
+	}
+	if tags, _ := entry.Metadata["tags"].(map[string]interface{}); tags["gateway"] != "stripe" {
+		t.Errorf("tags = %v", entry.Metadata["tags"])
+	}
+}
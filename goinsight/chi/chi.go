@@ -0,0 +1,78 @@
+// Package goinsightchi provides Go-Insight middleware for go-chi routers
+package goinsightchi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// chi exposes no version constant, so its version comes from the build info
+const chiModule = "github.com/go-chi/chi/v5"
+
+// Middleware returns a chi middleware for automatic instrumentation
+func Middleware(client *goinsight.Client, opts ...goinsight.MiddlewareOption) func(http.Handler) http.Handler {
+	inst := client.NewServerInstrumentation(goinsight.MetricSource{
+		Language:  "go",
+		Framework: "chi",
+		Version:   goinsight.ModuleVersion(chiModule),
+	}, opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if inst.Skip(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+
+			// Start trace for this request
+			ctx, traceCtx := inst.Start(r.Context(), r.Method, matchRoute(r))
+			r = r.WithContext(ctx)
+
+			// Process request
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			statusCode := ww.Status()
+			if statusCode == 0 {
+				statusCode = http.StatusOK
+			}
+
+			// chi fills in the route context while routing, so the final
+			// pattern is only known once the handler returns
+			route := ""
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+
+			inst.Finish(r.Context(), traceCtx, goinsight.CompletedRequest{
+				Method:         r.Method,
+				Route:          route,
+				StatusCode:     statusCode,
+				Duration:       time.Since(start),
+				RequestHeader:  r.Header,
+				ResponseHeader: ww.Header(),
+			})
+		})
+	}
+}
+
+// matchRoute resolves the route pattern before the request is routed, so the
+// root span can be named after it
+func matchRoute(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return ""
+	}
+
+	matchCtx := chi.NewRouteContext()
+	if !rctx.Routes.Match(matchCtx, r.Method, r.URL.Path) {
+		return ""
+	}
+	return matchCtx.RoutePattern()
+}
@@ -0,0 +1,8 @@
+module github.com/NathanSanchezDev/go-insight-go-sdk/goinsight/chi
+
+go 1.21
+
+require (
+	github.com/NathanSanchezDev/go-insight-go-sdk v0.2.0
+	github.com/go-chi/chi/v5 v5.0.12
+)
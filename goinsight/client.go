@@ -1,55 +1,239 @@
 package goinsight
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
 // Client represents the Go-Insight SDK client
 type Client struct {
-	apiKey      string
-	endpoint    string
-	client      *http.Client
 	serviceName string
+	timeout     time.Duration
+	resource    Resource
+	exporter    Exporter
+	sampler     Sampler
+	batch       *batcher
+	captured    *errorAggregator
+	limiter     *logLimiter
+	stats       *clientStats
+	breaker     *circuitBreaker
+	sinks       *sinkExporter
+	reporter    *statsReporter
+	redact      *Redactor
+	closed      atomic.Bool
+	levels      levels
+
+	logProcessors    []LogProcessor
+	spanProcessors   []SpanProcessor
+	metricProcessors []MetricProcessor
 }
 
-// New creates a new Go-Insight client
+// New creates a new Go-Insight client. It never fails; use NewClient to
+// have the configuration validated up front.
 func New(config Config) *Client {
+	return newClient(clientOptions{config: config})
+}
+
+// NewClient creates a client from options, validating them first. Without
+// WithExporter an endpoint and API key are required.
+//
+//	client, err := goinsight.NewClient(
+//		goinsight.WithEndpoint("https://insight.example.com"),
+//		goinsight.WithAPIKey(apiKey),
+//		goinsight.WithServiceName("checkout"),
+//		goinsight.WithHTTPClient(&http.Client{Transport: transport}),
+//	)
+func NewClient(opts ...Option) (*Client, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := o.config.validate(o.exporter == nil); err != nil {
+		return nil, err
+	}
+	if err := o.openConfigSink(); err != nil {
+		return nil, err
+	}
+	return newClient(o), nil
+}
+
+func newClient(o clientOptions) *Client {
+	config := o.config
 	if config.Timeout == 0 {
 		config.Timeout = 5 * time.Second
 	}
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = 100 * time.Millisecond
+	}
 
-	return &Client{
-		apiKey:      config.APIKey,
-		endpoint:    config.Endpoint,
+	stats := &clientStats{}
+	exporter := o.exporter
+	if exporter == nil {
+		httpClient := o.httpClient
+		if httpClient == nil {
+			httpClient = &http.Client{}
+		}
+		exporter = &httpExporter{
+			apiKey:   config.APIKey,
+			endpoint: config.Endpoint,
+			client:   httpClient,
+			timeout:  config.Timeout,
+			retries:  config.MaxRetries,
+			backoff:  config.RetryBackoff,
+			stats:    stats,
+		}
+	}
+	exporter = &statsExporter{next: exporter, stats: stats}
+
+	var breaker *circuitBreaker
+	if config.BreakerErrorRate > 0 || config.BreakerSlowCall > 0 {
+		breaker = newCircuitBreaker(config)
+		exporter = &breakerExporter{next: exporter, breaker: breaker, stats: stats}
+	}
+
+	// NewClient has opened the config's sink already; New ignores errors
+	o.openConfigSink()
+	var sinks *sinkExporter
+	if len(o.sinks) > 0 || len(o.fallbackSinks) > 0 {
+		sinks = &sinkExporter{next: exporter, tee: o.sinks, fallback: o.fallbackSinks}
+		exporter = sinks
+	}
+
+	sampler := o.sampler
+	if sampler == nil {
+		sampler = AlwaysSample()
+		if config.SampleRate > 0 {
+			sampler = RatioSampler(config.SampleRate)
+		}
+	}
+
+	if o.redactor == nil {
+		// NewClient has validated the names; New ignores unknown ones
+		o.redactor, _ = redactorFromConfig(config)
+	}
+
+	resource := DetectResource(config)
+	for k, v := range o.resource {
+		resource[k] = v
+	}
+
+	c := &Client{
 		serviceName: config.ServiceName,
-		client: &http.Client{
-			Timeout: config.Timeout,
-		},
+		timeout:     config.Timeout,
+		resource:    resource,
+		exporter:    exporter,
+		sampler:     sampler,
+		redact:      o.redactor,
+		stats:       stats,
+		breaker:     breaker,
+		sinks:       sinks,
+
+		logProcessors:    o.logProcessors,
+		spanProcessors:   o.spanProcessors,
+		metricProcessors: o.metricProcessors,
+	}
+	if o.minLevel != nil {
+		c.levels.min.Set(*o.minLevel)
+	} else if config.MinLevel != "" {
+		// NewClient has validated the levels; New ignores invalid ones
+		if level, err := ParseLevel(config.MinLevel); err == nil {
+			c.levels.min.Set(level)
+		}
+	}
+	for name, l := range config.LoggerLevels {
+		if level, err := ParseLevel(l); err == nil {
+			c.SetLoggerLevel(name, level)
+		}
+	}
+	c.captured = newErrorAggregator(config.ErrorWindow, func(event ErrorEvent) {
+		c.exportError(context.Background(), event)
+	})
+	if config.LogRateLimit > 0 {
+		c.limiter = newLogLimiter(config.LogRateLimit, config.LogRateBurst, config.LogSummaryInterval, c.reportSuppressed)
+	}
+	if config.BatchSize > 0 {
+		c.batch = newBatcher(config.BatchSize, config.FlushInterval, config.QueueSize)
+	}
+	if config.StatsInterval > 0 {
+		c.reporter = newStatsReporter(config.StatsInterval, c.reportStats)
+	}
+	return c
+}
+
+// Close sends the logs and metrics still queued, waiting up to the client
+// timeout, and shuts the exporter down, releasing idle connections. Every
+// later send fails with ErrClientClosed.
+func (c *Client) Close() error {
+	c.closed.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	// Aggregated errors and suppression reports go through the batch queue,
+	// so send them first
+	var errs []error
+	if c.reporter != nil {
+		errs = append(errs, c.reporter.shutdown(ctx))
+	}
+	errs = append(errs, c.captured.shutdown(ctx))
+	if c.limiter != nil {
+		errs = append(errs, c.limiter.shutdown(ctx))
+	}
+	if c.batch != nil {
+		errs = append(errs, c.batch.shutdown(ctx))
+	}
+	errs = append(errs, c.exporter.Shutdown(ctx))
+	return errors.Join(errs...)
+}
+
+// Flush blocks until the logs and metrics queued so far have been sent, or
+// ctx is done. It returns immediately when batching is disabled.
+func (c *Client) Flush(ctx context.Context) error {
+	if c.batch == nil {
+		return nil
 	}
+	return c.batch.flushQueue(ctx)
+}
+
+// Resource returns the attributes attached to everything the client sends.
+// The returned map must not be modified.
+func (c *Client) Resource() Resource {
+	return c.resource
 }
 
 // Log sends a log entry to Go-Insight
 func (c *Client) Log(ctx context.Context, level, message string, metadata map[string]interface{}) error {
-	traceCtx := GetTraceFromContext(ctx)
+	return c.logAt(ctx, time.Now(), level, message, metadata)
+}
+
+// logAt sends a log entry stamped with ts, for logs exported after the fact
+func (c *Client) logAt(ctx context.Context, ts time.Time, level, message string, metadata map[string]interface{}) error {
+	if !c.levelEnabled("", level) {
+		return nil
+	}
+	return c.writeLog(ctx, ts, level, message, metadata)
+}
 
+// writeLog sends a log entry that passed the level check
+func (c *Client) writeLog(ctx context.Context, ts time.Time, level, message string, metadata map[string]interface{}) error {
 	entry := LogEntry{
 		ServiceName: c.serviceName,
 		LogLevel:    level,
 		Message:     message,
+		Timestamp:   ts,
 		Metadata:    metadata,
+		Resource:    c.resource,
 	}
-
-	if traceCtx != nil {
-		entry.TraceID = traceCtx.TraceID
-		entry.SpanID = traceCtx.SpanID
+	if !c.processLog(ctx, &entry) {
+		return nil
 	}
 
-	return c.sendLog(entry)
+	return c.sendLog(ctx, entry)
 }
 
 // LogInfo sends an info log with optional metadata
@@ -61,8 +245,19 @@ func (c *Client) LogInfo(ctx context.Context, message string, metadata ...map[st
 	return c.Log(ctx, "INFO", message, meta)
 }
 
-// LogError sends an error log with optional error and metadata
+// LogError sends an error log with optional error and metadata. The error
+// is recorded with its type, chain, stack trace, fingerprint and the
+// caller's location; see ErrorKey and the keys next to it.
 func (c *Client) LogError(ctx context.Context, message string, errAndMetadata ...interface{}) error {
+	if !c.levels.enabled("", LevelError) {
+		return nil
+	}
+	return c.Log(ctx, "ERROR", message, errorMetadata(errAndMetadata))
+}
+
+// errorMetadata picks the error and metadata out of LogError's arguments,
+// returning a copy of the metadata with the error's fields added
+func errorMetadata(errAndMetadata []interface{}) map[string]interface{} {
 	var err error
 	var metadata map[string]interface{}
 
@@ -75,14 +270,11 @@ func (c *Client) LogError(ctx context.Context, message string, errAndMetadata ..
 		}
 	}
 
-	if metadata == nil {
-		metadata = make(map[string]interface{})
-	}
+	metadata = copyMap(metadata)
 	if err != nil {
-		metadata["error"] = err.Error()
+		addErrorFields(metadata, err)
 	}
-
-	return c.Log(ctx, "ERROR", message, metadata)
+	return metadata
 }
 
 // LogWarn sends a warning log with optional metadata
@@ -105,107 +297,166 @@ func (c *Client) LogDebug(ctx context.Context, message string, metadata ...map[s
 
 // SendMetric sends a performance metric to Go-Insight
 func (c *Client) SendMetric(metric Metric) error {
+	return c.SendMetricContext(context.Background(), metric)
+}
+
+// SendMetricContext sends a performance metric to Go-Insight, aborting the
+// request when ctx is cancelled or its deadline expires
+func (c *Client) SendMetricContext(ctx context.Context, metric Metric) error {
 	if metric.ServiceName == "" {
 		metric.ServiceName = c.serviceName
 	}
+	if metric.Environment == "" {
+		metric.Environment = c.resource[AttrEnvironment]
+	}
+	if metric.Resource == nil {
+		metric.Resource = c.resource
+	}
+	if !c.processMetric(ctx, &metric) {
+		return nil
+	}
 
-	return c.sendMetric(metric)
+	return c.sendMetric(ctx, metric)
 }
 
-// HTTP client methods
-func (c *Client) sendLog(entry LogEntry) error {
-	return c.sendRequest("POST", "/logs", entry)
+// WithRequestTimeout returns a context that overrides Config.Timeout for every
+// request the SDK sends with it. A deadline already set on ctx still applies
+// when it expires first.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
 }
 
-func (c *Client) sendMetric(metric Metric) error {
-	return c.sendRequest("POST", "/metrics", metric)
-}
+type requestTimeoutKey struct{}
 
-func (c *Client) sendTrace(trace Trace) (map[string]interface{}, error) {
-	var resp map[string]interface{}
-	err := c.sendRequestWithResponse("POST", "/traces", trace, &resp)
-	return resp, err
+// requestContext bounds ctx by the per-call override or timeout
+func requestContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if override, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok && override > 0 {
+		timeout = override
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
-func (c *Client) sendSpan(span Span) (map[string]interface{}, error) {
-	var resp map[string]interface{}
-	err := c.sendRequestWithResponse("POST", "/spans", span, &resp)
-	return resp, err
+// exportContext detaches ctx from the caller's cancellation so asynchronous
+// exports can outlive the request that produced them, while still keeping
+// its values and bounding the export by the client timeout
+func (c *Client) exportContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return requestContext(context.WithoutCancel(ctx), c.timeout)
 }
 
-func (c *Client) endSpan(spanID string) error {
-	return c.sendRequest("POST", fmt.Sprintf("/spans/%s/end", spanID), nil)
+// Export methods
+func (c *Client) sendLog(ctx context.Context, entry LogEntry) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	return c.exportLog(ctx, entry)
 }
 
-func (c *Client) endTrace(traceID string) error {
-	return c.sendRequest("POST", fmt.Sprintf("/traces/%s/end", traceID), nil)
+// exportLog skips the closed check so Close can report suppressed logs
+func (c *Client) exportLog(ctx context.Context, entry LogEntry) error {
+	if c.batch != nil {
+		return c.enqueue(SinkLog, entry, func(ctx context.Context) error {
+			return c.exporter.ExportLog(ctx, entry)
+		})
+	}
+	return c.exporter.ExportLog(ctx, entry)
 }
 
-func (c *Client) sendRequest(method, path string, data interface{}) error {
-	return c.sendRequestWithResponse(method, path, data, nil)
+func (c *Client) sendMetric(ctx context.Context, metric Metric) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	if c.batch != nil {
+		return c.enqueue(SinkMetric, metric, func(ctx context.Context) error {
+			return c.exporter.ExportMetric(ctx, metric)
+		})
+	}
+	return c.exporter.ExportMetric(ctx, metric)
 }
 
-func (c *Client) sendRequestWithResponse(method, path string, data interface{}, response interface{}) error {
-	var body []byte
-	var err error
-
-	if data != nil {
-		body, err = json.Marshal(data)
-		if err != nil {
-			return fmt.Errorf("failed to marshal request: %w", err)
-		}
+func (c *Client) sendError(ctx context.Context, event ErrorEvent) error {
+	if c.closed.Load() {
+		return ErrClientClosed
 	}
+	return c.exportError(ctx, event)
+}
 
-	req, err := http.NewRequest(method, c.endpoint+path, bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+// exportError skips the closed check so Close can send aggregated counts
+func (c *Client) exportError(ctx context.Context, event ErrorEvent) error {
+	if c.batch != nil {
+		return c.enqueue(SinkError, event, func(ctx context.Context) error {
+			return c.exporter.ExportError(ctx, event)
+		})
 	}
+	return c.exporter.ExportError(ctx, event)
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", c.apiKey)
+// enqueue queues an export on the batcher, counting it in the stats. A
+// record the full queue refuses goes to the sinks as a failed export.
+func (c *Client) enqueue(kind string, record interface{}, export func(ctx context.Context) error) error {
+	err := c.batch.enqueue(export)
+	c.stats.enqueued(err)
+	if err != nil && c.sinks != nil {
+		c.sinks.write(kind, "", record, err)
+	}
+	return err
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+func (c *Client) sendTrace(ctx context.Context, trace Trace) (string, error) {
+	if c.closed.Load() {
+		return "", ErrClientClosed
 	}
-	defer resp.Body.Close()
+	return c.exporter.StartTrace(ctx, trace)
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("API request failed with status %d", resp.StatusCode)
+func (c *Client) sendSpan(ctx context.Context, span Span) (string, error) {
+	if c.closed.Load() {
+		return "", ErrClientClosed
 	}
+	return c.exporter.StartSpan(ctx, span)
+}
 
-	if response != nil {
-		if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
-		}
+func (c *Client) endSpan(ctx context.Context, spanID string, end SpanEnd) error {
+	if c.closed.Load() {
+		return ErrClientClosed
 	}
+	return c.exporter.EndSpan(ctx, spanID, end)
+}
 
-	return nil
+func (c *Client) endTrace(ctx context.Context, traceID string) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	return c.exporter.EndTrace(ctx, traceID)
 }
 
 // Instrument wraps a function with automatic instrumentation
 func (c *Client) Instrument(operation string, fn func(ctx context.Context) error) func(ctx context.Context) error {
 	return func(ctx context.Context) error {
+		var fnErr error
+
 		// Start span
 		spanCtx, err := c.StartSpan(ctx, operation)
 		if err != nil {
 			// If we can't start span, still execute function
 			spanCtx = ctx
+		} else {
+			// Defer span finishing, detached so a cancelled caller still closes the span
+			defer func() {
+				finishCtx, cancel := c.exportContext(spanCtx)
+				defer cancel()
+
+				if err := c.FinishSpan(finishCtx, WithSpanError(fnErr)); err != nil {
+					// Log error but don't fail the operation
+					c.LogError(finishCtx, "Failed to finish span", err, map[string]interface{}{
+						"operation": operation,
+					})
+				}
+			}()
 		}
 
-		// Defer span finishing
-		defer func() {
-			if err := c.FinishSpan(spanCtx); err != nil {
-				// Log error but don't fail the operation
-				c.LogError(spanCtx, "Failed to finish span", err, map[string]interface{}{
-					"operation": operation,
-				})
-			}
-		}()
-
 		// Execute function
 		start := time.Now()
-		fnErr := fn(spanCtx)
+		fnErr = fn(spanCtx)
 		duration := time.Since(start)
 
 		// Log operation result
@@ -0,0 +1,484 @@
+package goinsight
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigEnv names the environment variable NewFromEnv reads a config file
+// path from
+const ConfigEnv = "GO_INSIGHT_CONFIG"
+
+// ConfigError describes one invalid configuration setting
+type ConfigError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("goinsight: invalid config: %s: %s", e.Field, e.Reason)
+}
+
+// Validate reports every invalid setting in c, joined into one error, or nil
+// when c is usable
+func (c Config) Validate() error {
+	return c.validate(true)
+}
+
+// validate checks c; the endpoint and API key only matter when the client
+// sends to the HTTP API itself
+func (c Config) validate(transport bool) error {
+	var errs []error
+	invalid := func(field, reason string) {
+		errs = append(errs, &ConfigError{Field: field, Reason: reason})
+	}
+
+	if transport {
+		if c.Endpoint == "" {
+			invalid("Endpoint", "must not be empty")
+		} else if u, err := url.Parse(c.Endpoint); err != nil {
+			invalid("Endpoint", fmt.Sprintf("malformed URL %q", c.Endpoint))
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			invalid("Endpoint", fmt.Sprintf("%q must use http or https", c.Endpoint))
+		} else if u.Host == "" {
+			invalid("Endpoint", fmt.Sprintf("%q has no host", c.Endpoint))
+		}
+		if c.APIKey == "" {
+			invalid("APIKey", "must not be empty")
+		}
+	}
+	if c.Timeout < 0 {
+		invalid("Timeout", "must not be negative")
+	}
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		invalid("SampleRate", fmt.Sprintf("%v is not between 0 and 1", c.SampleRate))
+	}
+	if c.BatchSize < 0 {
+		invalid("BatchSize", "must not be negative")
+	}
+	if c.FlushInterval < 0 {
+		invalid("FlushInterval", "must not be negative")
+	}
+	if c.QueueSize < 0 {
+		invalid("QueueSize", "must not be negative")
+	}
+	if c.MaxRetries < 0 {
+		invalid("MaxRetries", "must not be negative")
+	}
+	if c.RetryBackoff < 0 {
+		invalid("RetryBackoff", "must not be negative")
+	}
+	if c.ErrorWindow < 0 {
+		invalid("ErrorWindow", "must not be negative")
+	}
+	if c.LogRateLimit < 0 {
+		invalid("LogRateLimit", "must not be negative")
+	}
+	if c.LogRateBurst < 0 {
+		invalid("LogRateBurst", "must not be negative")
+	}
+	if c.LogSummaryInterval < 0 {
+		invalid("LogSummaryInterval", "must not be negative")
+	}
+	switch c.SinkType {
+	case "", "console", "json":
+	case "file":
+		if c.SinkPath == "" {
+			invalid("SinkPath", "must not be empty for a file sink")
+		}
+	default:
+		invalid("SinkType", fmt.Sprintf("unknown sink %q", c.SinkType))
+	}
+	switch c.SinkMode {
+	case "", SinkModeTee, SinkModeFallback:
+	default:
+		invalid("SinkMode", fmt.Sprintf("unknown mode %q", c.SinkMode))
+	}
+	if c.SinkMaxSize < 0 {
+		invalid("SinkMaxSize", "must not be negative")
+	}
+	if c.SinkMaxFiles < 0 {
+		invalid("SinkMaxFiles", "must not be negative")
+	}
+	if c.BreakerErrorRate < 0 || c.BreakerErrorRate > 1 {
+		invalid("BreakerErrorRate", fmt.Sprintf("%v is not between 0 and 1", c.BreakerErrorRate))
+	}
+	if c.BreakerMinRequests < 0 {
+		invalid("BreakerMinRequests", "must not be negative")
+	}
+	if c.BreakerWindow < 0 {
+		invalid("BreakerWindow", "must not be negative")
+	}
+	if c.BreakerSlowCall < 0 {
+		invalid("BreakerSlowCall", "must not be negative")
+	}
+	if c.BreakerOpenTimeout < 0 {
+		invalid("BreakerOpenTimeout", "must not be negative")
+	}
+	if c.StatsInterval < 0 {
+		invalid("StatsInterval", "must not be negative")
+	}
+	if _, err := redactorFromConfig(c); err != nil {
+		errs = append(errs, err)
+	}
+	if c.MinLevel != "" {
+		if _, err := ParseLevel(c.MinLevel); err != nil {
+			invalid("MinLevel", fmt.Sprintf("unknown level %q", c.MinLevel))
+		}
+	}
+	for name, level := range c.LoggerLevels {
+		if _, err := ParseLevel(level); err != nil {
+			invalid("LoggerLevels", fmt.Sprintf("unknown level %q for logger %q", level, name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NewFromEnv creates a client configured from the environment. The file
+// named by GO_INSIGHT_CONFIG is loaded first when set, then GO_INSIGHT_*
+// variables override it. The result is validated like NewClient's, and a
+// configured sink that can't be opened is an error.
+func NewFromEnv() (*Client, error) {
+	var config Config
+	if path := os.Getenv(ConfigEnv); path != "" {
+		var err error
+		if config, err = readConfigFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(&config, os.Getenv); err != nil {
+		return nil, err
+	}
+	return NewClient(WithConfig(config))
+}
+
+// LoadConfig reads and validates a JSON (.json) or YAML (.yaml, .yml)
+// config file. Unknown keys are rejected, and numbers given for string
+// settings are kept as written.
+//
+//	endpoint: https://insight.example.com
+//	api_key: secret
+//	service_name: checkout
+//	timeout: 3s
+//	sampling:
+//	  rate: 0.25
+//	batching:
+//	  size: 100
+//	  flush_interval: 2s
+//	retry:
+//	  max_retries: 3
+//	redaction:
+//	  keys: [password, authorization]
+//	  detectors: [email, card_number]
+//	  strategy: mask
+//	logging:
+//	  level: info
+//	  loggers: {db: debug}
+//	  rate_limit: {rate: 10, burst: 50}
+//	errors:
+//	  window: 1m
+//	sink:
+//	  type: file
+//	  mode: fallback
+//	  path: /var/log/checkout/telemetry.jsonl
+//	circuit_breaker:
+//	  error_rate: 0.5
+//	  slow_call: 2s
+//	stats:
+//	  interval: 1m
+func LoadConfig(path string) (Config, error) {
+	config, err := readConfigFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+func readConfigFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("goinsight: read config: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+	case ".yaml", ".yml":
+		doc, err := parseYAML(data)
+		if err != nil {
+			return Config{}, fmt.Errorf("goinsight: parse config %s: %w", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return Config{}, fmt.Errorf("goinsight: parse config %s: %w", path, err)
+		}
+	default:
+		return Config{}, fmt.Errorf("goinsight: config %s: unsupported format %q, use .json, .yaml or .yml", path, ext)
+	}
+
+	var file fileConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		// Decoding goes through JSON for YAML files too, so drop its prefix
+		return Config{}, fmt.Errorf("goinsight: parse config %s: %s", path, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return file.config(), nil
+}
+
+// fileConfig is the layout of a config file
+type fileConfig struct {
+	APIKey             text            `json:"api_key"`
+	Endpoint           text            `json:"endpoint"`
+	ServiceName        text            `json:"service_name"`
+	ServiceVersion     text            `json:"service_version"`
+	Environment        text            `json:"environment"`
+	Timeout            duration        `json:"timeout"`
+	ResourceAttributes map[string]text `json:"resource_attributes"`
+
+	Sampling struct {
+		Rate float64 `json:"rate"`
+	} `json:"sampling"`
+
+	Batching struct {
+		Size          int      `json:"size"`
+		FlushInterval duration `json:"flush_interval"`
+		QueueSize     int      `json:"queue_size"`
+	} `json:"batching"`
+
+	Retry struct {
+		MaxRetries int      `json:"max_retries"`
+		Backoff    duration `json:"backoff"`
+	} `json:"retry"`
+
+	Redaction struct {
+		Keys      []text `json:"keys"`
+		Detectors []text `json:"detectors"`
+		Strategy  text   `json:"strategy"`
+	} `json:"redaction"`
+
+	Sink struct {
+		Type     text  `json:"type"`
+		Mode     text  `json:"mode"`
+		Path     text  `json:"path"`
+		MaxSize  int64 `json:"max_size"`
+		MaxFiles int   `json:"max_files"`
+	} `json:"sink"`
+
+	CircuitBreaker struct {
+		ErrorRate   float64  `json:"error_rate"`
+		MinRequests int      `json:"min_requests"`
+		Window      duration `json:"window"`
+		SlowCall    duration `json:"slow_call"`
+		OpenTimeout duration `json:"open_timeout"`
+	} `json:"circuit_breaker"`
+
+	Stats struct {
+		Interval duration `json:"interval"`
+	} `json:"stats"`
+
+	Errors struct {
+		Window duration `json:"window"`
+	} `json:"errors"`
+
+	Logging struct {
+		Level     text            `json:"level"`
+		Loggers   map[string]text `json:"loggers"`
+		RateLimit struct {
+			Rate            float64  `json:"rate"`
+			Burst           int      `json:"burst"`
+			SummaryInterval duration `json:"summary_interval"`
+		} `json:"rate_limit"`
+	} `json:"logging"`
+}
+
+func (f fileConfig) config() Config {
+	return Config{
+		APIKey:             string(f.APIKey),
+		Endpoint:           string(f.Endpoint),
+		ServiceName:        string(f.ServiceName),
+		ServiceVersion:     string(f.ServiceVersion),
+		Environment:        string(f.Environment),
+		Timeout:            time.Duration(f.Timeout),
+		ResourceAttributes: textMap(f.ResourceAttributes),
+		SampleRate:         f.Sampling.Rate,
+		BatchSize:          f.Batching.Size,
+		FlushInterval:      time.Duration(f.Batching.FlushInterval),
+		QueueSize:          f.Batching.QueueSize,
+		MaxRetries:         f.Retry.MaxRetries,
+		RetryBackoff:       time.Duration(f.Retry.Backoff),
+		RedactKeys:         textList(f.Redaction.Keys),
+		RedactDetectors:    textList(f.Redaction.Detectors),
+		RedactStrategy:     string(f.Redaction.Strategy),
+		MinLevel:           string(f.Logging.Level),
+		LoggerLevels:       textMap(f.Logging.Loggers),
+		ErrorWindow:        time.Duration(f.Errors.Window),
+		LogRateLimit:       f.Logging.RateLimit.Rate,
+		LogRateBurst:       f.Logging.RateLimit.Burst,
+		LogSummaryInterval: time.Duration(f.Logging.RateLimit.SummaryInterval),
+		SinkType:           string(f.Sink.Type),
+		SinkMode:           string(f.Sink.Mode),
+		SinkPath:           string(f.Sink.Path),
+		SinkMaxSize:        f.Sink.MaxSize,
+		SinkMaxFiles:       f.Sink.MaxFiles,
+		BreakerErrorRate:   f.CircuitBreaker.ErrorRate,
+		BreakerMinRequests: f.CircuitBreaker.MinRequests,
+		BreakerWindow:      time.Duration(f.CircuitBreaker.Window),
+		BreakerSlowCall:    time.Duration(f.CircuitBreaker.SlowCall),
+		BreakerOpenTimeout: time.Duration(f.CircuitBreaker.OpenTimeout),
+		StatsInterval:      time.Duration(f.Stats.Interval),
+	}
+}
+
+// text decodes a string, or takes a number or bool literal as written, so
+// api_key: 12345 and service_version: 1.10 load as "12345" and "1.10"
+type text string
+
+func (t *text) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = text(s)
+		return nil
+	}
+	if data[0] == '{' || data[0] == '[' {
+		return fmt.Errorf("expected a string, found %s", data)
+	}
+	*t = text(data)
+	return nil
+}
+
+func textList(list []text) []string {
+	if list == nil {
+		return nil
+	}
+	out := make([]string, len(list))
+	for i, v := range list {
+		out[i] = string(v)
+	}
+	return out
+}
+
+func textMap(m map[string]text) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = string(v)
+	}
+	return out
+}
+
+// duration decodes a Go duration string such as "1.5s", or a number of
+// seconds
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		*d = duration(parsed)
+		return nil
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = duration(seconds * float64(time.Second))
+	return nil
+}
+
+// applyEnv overrides config with the GO_INSIGHT_* variables that are set
+func applyEnv(config *Config, getenv func(string) string) error {
+	var errs []error
+	str := func(name string, dst *string) {
+		if v := getenv(name); v != "" {
+			*dst = v
+		}
+	}
+	integer := func(name string, dst *int) {
+		if v := getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, &ConfigError{Field: name, Reason: fmt.Sprintf("%q is not an integer", v)})
+				return
+			}
+			*dst = n
+		}
+	}
+	float := func(name string, dst *float64) {
+		if v := getenv(name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				errs = append(errs, &ConfigError{Field: name, Reason: fmt.Sprintf("%q is not a number", v)})
+				return
+			}
+			*dst = f
+		}
+	}
+	list := func(name string, dst *[]string) {
+		if v := getenv(name); v != "" {
+			*dst = nil
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*dst = append(*dst, item)
+				}
+			}
+		}
+	}
+	dur := func(name string, dst *time.Duration) {
+		if v := getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, &ConfigError{Field: name, Reason: fmt.Sprintf("%q is not a duration", v)})
+				return
+			}
+			*dst = d
+		}
+	}
+
+	str("GO_INSIGHT_API_KEY", &config.APIKey)
+	str("GO_INSIGHT_ENDPOINT", &config.Endpoint)
+	str("GO_INSIGHT_SERVICE_NAME", &config.ServiceName)
+	str("GO_INSIGHT_SERVICE_VERSION", &config.ServiceVersion)
+	dur("GO_INSIGHT_TIMEOUT", &config.Timeout)
+	float("GO_INSIGHT_SAMPLE_RATE", &config.SampleRate)
+	integer("GO_INSIGHT_BATCH_SIZE", &config.BatchSize)
+	dur("GO_INSIGHT_FLUSH_INTERVAL", &config.FlushInterval)
+	integer("GO_INSIGHT_QUEUE_SIZE", &config.QueueSize)
+	integer("GO_INSIGHT_MAX_RETRIES", &config.MaxRetries)
+	dur("GO_INSIGHT_RETRY_BACKOFF", &config.RetryBackoff)
+	list("GO_INSIGHT_REDACT_KEYS", &config.RedactKeys)
+	list("GO_INSIGHT_REDACT_DETECTORS", &config.RedactDetectors)
+	str("GO_INSIGHT_REDACT_STRATEGY", &config.RedactStrategy)
+	str("GO_INSIGHT_LOG_LEVEL", &config.MinLevel)
+	dur("GO_INSIGHT_ERROR_WINDOW", &config.ErrorWindow)
+	float("GO_INSIGHT_LOG_RATE_LIMIT", &config.LogRateLimit)
+	integer("GO_INSIGHT_LOG_RATE_BURST", &config.LogRateBurst)
+	str("GO_INSIGHT_SINK", &config.SinkType)
+	str("GO_INSIGHT_SINK_MODE", &config.SinkMode)
+	str("GO_INSIGHT_SINK_PATH", &config.SinkPath)
+	float("GO_INSIGHT_BREAKER_ERROR_RATE", &config.BreakerErrorRate)
+	dur("GO_INSIGHT_BREAKER_SLOW_CALL", &config.BreakerSlowCall)
+	dur("GO_INSIGHT_STATS_INTERVAL", &config.StatsInterval)
+
+	return errors.Join(errs...)
+}
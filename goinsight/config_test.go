@@ -0,0 +1,190 @@
+package goinsight
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigYAMLScalars(t *testing.T) {
+	path := writeConfig(t, "goinsight.yaml", `
+endpoint: https://insight.example.com
+api_key: 12345
+service_name: true
+service_version: 1.10
+environment: 007
+timeout: 1.5
+resource_attributes:
+  build: 1.10
+  replicas: 3
+  canary: false
+sampling:
+  rate: 0.25
+batching:
+  size: 100
+`)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.APIKey != "12345" || config.ServiceName != "true" || config.ServiceVersion != "1.10" || config.Environment != "007" {
+		t.Errorf("string fields = %q, %q, %q, %q", config.APIKey, config.ServiceName, config.ServiceVersion, config.Environment)
+	}
+	if config.Timeout != 1500*time.Millisecond {
+		t.Errorf("Timeout = %v", config.Timeout)
+	}
+	want := map[string]string{"build": "1.10", "replicas": "3", "canary": "false"}
+	for k, v := range want {
+		if config.ResourceAttributes[k] != v {
+			t.Errorf("ResourceAttributes[%q] = %q, want %q", k, config.ResourceAttributes[k], v)
+		}
+	}
+	if config.SampleRate != 0.25 || config.BatchSize != 100 {
+		t.Errorf("SampleRate = %v, BatchSize = %v", config.SampleRate, config.BatchSize)
+	}
+}
+
+func TestLoadConfigScalarLists(t *testing.T) {
+	path := writeConfig(t, "goinsight.yaml", `
+endpoint: http://localhost:8080
+api_key: secret
+service_name: checkout
+redaction:
+  keys: [password, 4111, true]
+  detectors:
+    - email
+logging:
+  level: info
+  loggers:
+    db: debug
+    7: warn
+`)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(config.RedactKeys, ","); got != "password,4111,true" {
+		t.Errorf("RedactKeys = %q", config.RedactKeys)
+	}
+	if len(config.RedactDetectors) != 1 || config.RedactDetectors[0] != "email" {
+		t.Errorf("RedactDetectors = %q", config.RedactDetectors)
+	}
+	if config.LoggerLevels["db"] != "debug" || config.LoggerLevels["7"] != "warn" {
+		t.Errorf("LoggerLevels = %v", config.LoggerLevels)
+	}
+}
+
+func TestLoadConfigJSONNumberAsString(t *testing.T) {
+	path := writeConfig(t, "goinsight.json", `{"endpoint": "http://localhost:8080", "api_key": 12345, "service_name": "checkout"}`)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.APIKey != "12345" {
+		t.Errorf("APIKey = %q", config.APIKey)
+	}
+}
+
+func TestLoadConfigRejectsMappingForString(t *testing.T) {
+	path := writeConfig(t, "goinsight.yaml", `
+endpoint: http://localhost:8080
+api_key: {value: secret}
+service_name: checkout
+`)
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig accepted a mapping for api_key")
+	}
+}
+
+func TestNewFromEnvSinkError(t *testing.T) {
+	t.Setenv(ConfigEnv, "")
+	t.Setenv("GO_INSIGHT_ENDPOINT", "http://localhost:8080")
+	t.Setenv("GO_INSIGHT_API_KEY", "secret")
+	t.Setenv("GO_INSIGHT_SERVICE_NAME", "checkout")
+	t.Setenv("GO_INSIGHT_SINK", "file")
+	t.Setenv("GO_INSIGHT_SINK_PATH", filepath.Join(t.TempDir(), "missing", "telemetry.jsonl"))
+
+	client, err := NewFromEnv()
+	if err == nil {
+		client.Close()
+		t.Fatal("NewFromEnv ignored a sink that can't be opened")
+	}
+}
+
+func TestNewClientValidation(t *testing.T) {
+	transport := []Option{WithEndpoint("http://localhost:8080"), WithAPIKey("secret"), WithServiceName("checkout")}
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{"valid", transport, nil},
+		{"missing transport", []Option{WithServiceName("checkout")}, []string{"APIKey", "Endpoint"}},
+		{"bad scheme", []Option{WithEndpoint("ftp://localhost"), WithAPIKey("secret"), WithServiceName("checkout")}, []string{"Endpoint"}},
+		{"exporter needs no transport", []Option{WithServiceName("checkout"), WithExporter(&recordingExporter{})}, nil},
+		{"several options", append(transport, WithBatching(-1, -time.Second), WithRetry(-1, 0)), []string{"BatchSize", "FlushInterval", "MaxRetries"}},
+		{"config", []Option{WithConfig(Config{
+			Endpoint:    "http://localhost:8080",
+			APIKey:      "secret",
+			ServiceName: "checkout",
+			SampleRate:  2,
+			SinkType:    "syslog",
+		})}, []string{"SampleRate", "SinkType"}},
+		{"file sink without path", []Option{WithConfig(Config{
+			Endpoint:    "http://localhost:8080",
+			APIKey:      "secret",
+			ServiceName: "checkout",
+			SinkType:    "file",
+		})}, []string{"SinkPath"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(tt.opts...)
+			if err == nil {
+				client.Close()
+			}
+
+			var fields []string
+			for _, e := range joined(err) {
+				var configErr *ConfigError
+				if !errors.As(e, &configErr) {
+					t.Fatalf("error %v is not a *ConfigError", e)
+				}
+				fields = append(fields, configErr.Field)
+			}
+			sort.Strings(fields)
+			if strings.Join(fields, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("invalid fields %v, want %v (%v)", fields, tt.want, err)
+			}
+		})
+	}
+}
+
+// joined returns the errors wrapped by an errors.Join error, or err itself
+func joined(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		return j.Unwrap()
+	}
+	return []error{err}
+}
@@ -0,0 +1,473 @@
+package goinsight
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+)
+
+// maxStatementLength caps the statement recorded on database spans
+const maxStatementLength = 4096
+
+// DBOption configures the database/sql instrumentation
+type DBOption func(*dbConfig)
+
+type dbConfig struct {
+	system       string
+	placeholders bool
+}
+
+// WithDBSystem records the database product on every span, e.g. "postgresql"
+// or "mysql"
+func WithDBSystem(system string) DBOption {
+	return func(cfg *dbConfig) {
+		cfg.system = system
+	}
+}
+
+// WithLiteralPlaceholders replaces string and numeric literals in recorded
+// statements with "?", so values inlined into SQL never leave the process
+func WithLiteralPlaceholders() DBOption {
+	return func(cfg *dbConfig) {
+		cfg.placeholders = true
+	}
+}
+
+// WrapDriver returns a driver that creates a child span of the request's
+// trace for every Query, Exec, Prepare, Begin, Commit and Rollback made on
+// connections it opens. Calls whose context carries no trace run untraced.
+func WrapDriver(client *Client, d driver.Driver, opts ...DBOption) driver.Driver {
+	cfg := &dbConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	t := &dbTracer{client: client, cfg: cfg}
+	if dc, ok := d.(driver.DriverContext); ok {
+		return &tracedDriverContext{tracedDriver{Driver: d, tracer: t}, dc}
+	}
+	return &tracedDriver{Driver: d, tracer: t}
+}
+
+// OpenDB opens a database like sql.Open, with every connection instrumented
+// by WrapDriver. driverName must already be registered with database/sql.
+func OpenDB(client *Client, driverName, dsn string, opts ...DBOption) (*sql.DB, error) {
+	// sql.Open doesn't connect, it only resolves the registered driver
+	probe, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := probe.Driver()
+	probe.Close()
+
+	wrapped := WrapDriver(client, d, opts...)
+	if dc, ok := wrapped.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(connector), nil
+	}
+	return sql.OpenDB(dsnConnector{dsn: dsn, driver: wrapped}), nil
+}
+
+// dsnConnector opens connections for drivers that don't implement
+// driver.DriverContext
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// dbTracer creates the spans for a wrapped driver
+type dbTracer struct {
+	client *Client
+	cfg    *dbConfig
+}
+
+// start opens a span for operation when ctx carries a trace. The returned
+// finish function must be called with the outcome; it is a no-op when no
+// span was started.
+func (t *dbTracer) start(ctx context.Context, operation, query string, opts ...SpanOption) func(err error, attrs map[string]interface{}) {
+	if GetTraceFromContext(ctx) == nil {
+		return func(error, map[string]interface{}) {}
+	}
+
+	attrs := map[string]interface{}{
+		"db.operation": operation,
+	}
+	if t.cfg.system != "" {
+		attrs["db.system"] = t.cfg.system
+	}
+	if query != "" {
+		attrs["db.statement"] = SanitizeStatement(query, t.cfg.placeholders)
+	}
+
+	opts = append(opts, WithSpanKind(SpanKindClient), WithAttributes(attrs))
+	spanCtx, err := t.client.StartSpan(ctx, "db."+operation, opts...)
+	if err != nil {
+		return func(error, map[string]interface{}) {}
+	}
+
+	return func(err error, attrs map[string]interface{}) {
+		finishCtx, cancel := t.client.exportContext(spanCtx)
+		defer cancel()
+
+		t.client.FinishSpan(finishCtx, WithSpanError(err), WithAttributes(attrs))
+	}
+}
+
+// startSkippable is start for calls a driver may refuse with driver.ErrSkip,
+// which asks database/sql to retry through Prepare. The span is only created
+// once the call returns, backdated to when it began, so a skipped call
+// records nothing and the fallback's spans are the only ones.
+func (t *dbTracer) startSkippable(ctx context.Context, operation, query string) func(err error, attrs map[string]interface{}) {
+	if GetTraceFromContext(ctx) == nil {
+		return func(error, map[string]interface{}) {}
+	}
+
+	began := time.Now()
+	return func(err error, attrs map[string]interface{}) {
+		if errors.Is(err, driver.ErrSkip) {
+			return
+		}
+		t.start(ctx, operation, query, WithTimestamp(began))(err, attrs)
+	}
+}
+
+// SanitizeStatement prepares a SQL statement for recording on a span: runs
+// of whitespace collapse to a single space and the result is capped at 4KB.
+// With placeholders set, string and numeric literals are replaced with "?".
+// The database integrations share it so statements look the same everywhere.
+func SanitizeStatement(query string, placeholders bool) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if placeholders {
+		query = replaceLiterals(query)
+	}
+	if len(query) > maxStatementLength {
+		query = query[:maxStatementLength]
+	}
+	return query
+}
+
+// replaceLiterals swaps quoted strings and standalone numbers, including
+// hex and exponent forms such as 0xFF and 1e10, for "?". Double-quoted
+// strings are replaced too: they are string literals in MySQL, and a quoted
+// identifier lost in Postgres is better than a value leaked. Identifiers
+// such as table1 and bind parameters such as $1 are kept.
+func replaceLiterals(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'' || ch == '"':
+			// Skip to the closing quote. A doubled quote is an escaped one,
+			// and so is a backslashed one (MySQL, PostgreSQL E'' strings);
+			// where a backslash is literal this over-redacts, never leaks.
+			for i++; i < len(query); i++ {
+				if query[i] == '\\' {
+					i++
+					continue
+				}
+				if query[i] == ch {
+					if i+1 < len(query) && query[i+1] == ch {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteByte('?')
+		case isDigit(ch) && (i == 0 || !isIdentByte(query[i-1])):
+			// Letters cover hex digits, radix prefixes and exponents
+			for i+1 < len(query) && isNumberByte(query[i+1]) {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// isNumberByte reports whether ch can continue a numeric literal. Casts
+// such as 1::int stop at the colon.
+func isNumberByte(ch byte) bool {
+	return ch == '.' || ch == '_' || isDigit(ch) || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// isIdentByte reports whether ch can precede a digit inside an identifier or
+// bind parameter
+func isIdentByte(ch byte) bool {
+	return ch == '_' || ch == '$' || ch == ':' || ch == '@' || ch == '?' ||
+		isDigit(ch) || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// rowsAffected reads the affected row count from result, when available
+func rowsAffected(result driver.Result) map[string]interface{} {
+	if result == nil {
+		return nil
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return nil
+	}
+	return map[string]interface{}{"db.rows_affected": n}
+}
+
+type tracedDriver struct {
+	driver.Driver
+	tracer *dbTracer
+}
+
+func (d *tracedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, tracer: d.tracer}, nil
+}
+
+type tracedDriverContext struct {
+	tracedDriver
+	dc driver.DriverContext
+}
+
+func (d *tracedDriverContext) OpenConnector(name string) (driver.Connector, error) {
+	connector, err := d.dc.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConnector{Connector: connector, driver: d}, nil
+}
+
+type tracedConnector struct {
+	driver.Connector
+	driver *tracedDriverContext
+}
+
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, tracer: c.driver.tracer}, nil
+}
+
+func (c *tracedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// tracedConn implements every optional connection interface and falls back
+// to driver.ErrSkip, or the plain method, when the wrapped connection lacks it
+type tracedConn struct {
+	driver.Conn
+	tracer *dbTracer
+}
+
+func (c *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	finish := c.tracer.start(ctx, "Prepare", query)
+
+	var stmt driver.Stmt
+	var err error
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = pc.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	finish(err, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, query: query, tracer: c.tracer}, nil
+}
+
+func (c *tracedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	finish := c.tracer.start(ctx, "Begin", "")
+
+	var tx driver.Tx
+	var err error
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = bc.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	finish(err, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{Tx: tx, ctx: ctx, tracer: c.tracer}, nil
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	finish := c.tracer.startSkippable(ctx, "Exec", query)
+	result, err := ec.ExecContext(ctx, query, args)
+	finish(err, rowsAffected(result))
+	return result, err
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	finish := c.tracer.startSkippable(ctx, "Query", query)
+	rows, err := qc.QueryContext(ctx, query, args)
+	finish(err, nil)
+	return rows, err
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type tracedStmt struct {
+	driver.Stmt
+	query  string
+	tracer *dbTracer
+}
+
+func (s *tracedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	finish := s.tracer.start(context.Background(), "Exec", s.query)
+	result, err := s.Stmt.Exec(args)
+	finish(err, rowsAffected(result))
+	return result, err
+}
+
+func (s *tracedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	finish := s.tracer.start(context.Background(), "Query", s.query)
+	rows, err := s.Stmt.Query(args)
+	finish(err, nil)
+	return rows, err
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	finish := s.tracer.start(ctx, "Exec", s.query)
+
+	var result driver.Result
+	var err error
+	if ec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = ec.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			result, err = s.Stmt.Exec(values)
+		}
+	}
+	finish(err, rowsAffected(result))
+	return result, err
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	finish := s.tracer.start(ctx, "Query", s.query)
+
+	var rows driver.Rows
+	var err error
+	if qc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = qc.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	finish(err, nil)
+	return rows, err
+}
+
+func (s *tracedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// namedValues converts arguments for drivers that only accept positional
+// values
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("goinsight: driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// tracedTx keeps the context from BeginTx, since driver.Tx has no context
+// aware Commit or Rollback
+type tracedTx struct {
+	driver.Tx
+	ctx    context.Context
+	tracer *dbTracer
+}
+
+func (t *tracedTx) Commit() error {
+	finish := t.tracer.start(t.ctx, "Commit", "")
+	err := t.Tx.Commit()
+	finish(err, nil)
+	return err
+}
+
+func (t *tracedTx) Rollback() error {
+	finish := t.tracer.start(t.ctx, "Rollback", "")
+	err := t.Tx.Rollback()
+	finish(err, nil)
+	return err
+}
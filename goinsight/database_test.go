@@ -0,0 +1,327 @@
+package goinsight
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeDriver answers every statement without a database. Statements
+// containing "fail" return errFake; the connection refuses those containing
+// "skip" with driver.ErrSkip, so database/sql prepares them instead.
+type fakeDriver struct{}
+
+var errFake = errors.New("fake failure")
+
+func init() {
+	sql.Register("goinsight-fake", fakeDriver{})
+}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.Contains(query, "fail") {
+		return nil, errFake
+	}
+	return &fakeStmt{query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "skip") {
+		return nil, driver.ErrSkip
+	}
+	return fakeExec(query)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "skip") {
+		return nil, driver.ErrSkip
+	}
+	return fakeQuery(query)
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return fakeExec(s.query)
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return fakeQuery(s.query)
+}
+
+func fakeExec(query string) (driver.Result, error) {
+	if strings.Contains(query, "fail") {
+		return nil, errFake
+	}
+	return driver.RowsAffected(3), nil
+}
+
+func fakeQuery(query string) (driver.Rows, error) {
+	if strings.Contains(query, "fail") {
+		return nil, errFake
+	}
+	return fakeRows{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"id"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+type wantSpan struct {
+	operation string
+	statement string
+	err       bool
+}
+
+func TestWrapDriverSpans(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, db *sql.DB) error
+		want []wantSpan
+	}{
+		{
+			name: "query",
+			run: func(ctx context.Context, db *sql.DB) error {
+				rows, err := db.QueryContext(ctx, "SELECT id FROM users WHERE email = 'a@b.c'")
+				if err != nil {
+					return err
+				}
+				return rows.Close()
+			},
+			want: []wantSpan{{operation: "db.Query", statement: "SELECT id FROM users WHERE email = ?"}},
+		},
+		{
+			name: "query error",
+			run: func(ctx context.Context, db *sql.DB) error {
+				_, err := db.QueryContext(ctx, "SELECT fail")
+				return err
+			},
+			want: []wantSpan{{operation: "db.Query", statement: "SELECT fail", err: true}},
+		},
+		{
+			name: "exec",
+			run: func(ctx context.Context, db *sql.DB) error {
+				_, err := db.ExecContext(ctx, "UPDATE users SET age = 42")
+				return err
+			},
+			want: []wantSpan{{operation: "db.Exec", statement: "UPDATE users SET age = ?"}},
+		},
+		{
+			name: "exec skipped",
+			run: func(ctx context.Context, db *sql.DB) error {
+				_, err := db.ExecContext(ctx, "UPDATE skip SET age = 42")
+				return err
+			},
+			want: []wantSpan{
+				{operation: "db.Prepare", statement: "UPDATE skip SET age = ?"},
+				{operation: "db.Exec", statement: "UPDATE skip SET age = ?"},
+			},
+		},
+		{
+			name: "query skipped",
+			run: func(ctx context.Context, db *sql.DB) error {
+				rows, err := db.QueryContext(ctx, "SELECT id FROM skip WHERE id = 7")
+				if err != nil {
+					return err
+				}
+				return rows.Close()
+			},
+			want: []wantSpan{
+				{operation: "db.Prepare", statement: "SELECT id FROM skip WHERE id = ?"},
+				{operation: "db.Query", statement: "SELECT id FROM skip WHERE id = ?"},
+			},
+		},
+		{
+			name: "prepare",
+			run: func(ctx context.Context, db *sql.DB) error {
+				stmt, err := db.PrepareContext(ctx, "DELETE FROM users WHERE id = $1")
+				if err != nil {
+					return err
+				}
+				defer stmt.Close()
+				_, err = stmt.ExecContext(ctx, 7)
+				return err
+			},
+			want: []wantSpan{
+				{operation: "db.Prepare", statement: "DELETE FROM users WHERE id = $1"},
+				{operation: "db.Exec", statement: "DELETE FROM users WHERE id = $1"},
+			},
+		},
+		{
+			name: "prepare error",
+			run: func(ctx context.Context, db *sql.DB) error {
+				_, err := db.PrepareContext(ctx, "SELECT fail")
+				return err
+			},
+			want: []wantSpan{{operation: "db.Prepare", statement: "SELECT fail", err: true}},
+		},
+		{
+			name: "commit",
+			run: func(ctx context.Context, db *sql.DB) error {
+				tx, err := db.BeginTx(ctx, nil)
+				if err != nil {
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, "INSERT INTO users VALUES (1)"); err != nil {
+					return err
+				}
+				return tx.Commit()
+			},
+			want: []wantSpan{
+				{operation: "db.Begin"},
+				{operation: "db.Exec", statement: "INSERT INTO users VALUES (?)"},
+				{operation: "db.Commit"},
+			},
+		},
+		{
+			name: "rollback",
+			run: func(ctx context.Context, db *sql.DB) error {
+				tx, err := db.BeginTx(ctx, nil)
+				if err != nil {
+					return err
+				}
+				return tx.Rollback()
+			},
+			want: []wantSpan{{operation: "db.Begin"}, {operation: "db.Rollback"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := &recordingExporter{}
+			client, err := NewClient(WithServiceName("db-test"), WithExporter(exporter))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			db, err := OpenDB(client, "goinsight-fake", "", WithDBSystem("postgresql"), WithLiteralPlaceholders())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			ctx, _, err := client.StartTrace(context.Background(), "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			runErr := tt.run(ctx, db)
+			if wantErr := len(tt.want) > 0 && tt.want[len(tt.want)-1].err; (runErr != nil) != wantErr {
+				t.Fatalf("run error = %v, want error %v", runErr, wantErr)
+			}
+
+			// StartTrace's root span comes first
+			spans := exporter.spans[1:]
+			if len(spans) != len(tt.want) {
+				t.Fatalf("got %d spans, want %d: %+v", len(spans), len(tt.want), spans)
+			}
+			for i, want := range tt.want {
+				span := spans[i]
+				if span.Operation != want.operation {
+					t.Errorf("span %d: operation %q, want %q", i, span.Operation, want.operation)
+				}
+				if span.Kind != SpanKindClient {
+					t.Errorf("span %d: kind %q, want client", i, span.Kind)
+				}
+				if span.Attributes["db.system"] != "postgresql" {
+					t.Errorf("span %d: db.system %v", i, span.Attributes["db.system"])
+				}
+				if got, _ := span.Attributes["db.statement"].(string); got != want.statement {
+					t.Errorf("span %d: db.statement %q, want %q", i, got, want.statement)
+				}
+
+				end, ok := exporter.ends[fmt.Sprintf("span-%d", i+2)]
+				if !ok {
+					t.Errorf("span %d was not finished", i)
+					continue
+				}
+				if want.err != (end.Status == SpanStatusError) {
+					t.Errorf("span %d: status %q, error %q", i, end.Status, end.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestWrapDriverWithoutTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	client, err := NewClient(WithServiceName("db-test"), WithExporter(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	db, err := OpenDB(client, "goinsight-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(context.Background(), "UPDATE users SET age = 42"); err != nil {
+		t.Fatal(err)
+	}
+	if len(exporter.spans) != 0 {
+		t.Errorf("got %d spans for a call without a trace", len(exporter.spans))
+	}
+}
+
+func TestSanitizeStatement(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		placeholders bool
+		want         string
+	}{
+		{"whitespace", "SELECT *\n\tFROM  users", false, "SELECT * FROM users"},
+		{"literals kept without placeholders", "SELECT * FROM users WHERE id = 7", false, "SELECT * FROM users WHERE id = 7"},
+		{"single-quoted string", "WHERE email = 'a@b.c'", true, "WHERE email = ?"},
+		{"escaped single quote", "WHERE name = 'O''Brien' AND id = 1", true, "WHERE name = ? AND id = ?"},
+		{"backslash-escaped quote", `WHERE a = 'x\'y' AND b = 'secret'`, true, "WHERE a = ? AND b = ?"},
+		{"escaped backslash", `WHERE path = 'C:\\' AND b = 'secret'`, true, "WHERE path = ? AND b = ?"},
+		{"double-quoted string", `WHERE name = "secret"`, true, "WHERE name = ?"},
+		{"escaped double quote", `WHERE name = "a""b" AND x = 1`, true, "WHERE name = ? AND x = ?"},
+		{"integer", "LIMIT 10 OFFSET 20", true, "LIMIT ? OFFSET ?"},
+		{"decimal", "WHERE price > 9.99", true, "WHERE price > ?"},
+		{"hex", "WHERE flags = 0xFF", true, "WHERE flags = ?"},
+		{"exponent", "WHERE x < 1e10", true, "WHERE x < ?"},
+		{"cast", "WHERE id = 1::int", true, "WHERE id = ?::int"},
+		{"identifiers", "SELECT col1 FROM table2", true, "SELECT col1 FROM table2"},
+		{"bind parameters", "WHERE a = $1 AND b = :2 AND c = @3 AND d = ?", true, "WHERE a = $1 AND b = :2 AND c = @3 AND d = ?"},
+		{"unterminated string", "WHERE name = 'abc", true, "WHERE name = ?"},
+		{"truncated", strings.Repeat("x", maxStatementLength+10), false, strings.Repeat("x", maxStatementLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeStatement(tt.query, tt.placeholders); got != tt.want {
+				t.Errorf("SanitizeStatement(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Package goinsightecho provides Go-Insight middleware for Echo
+package goinsightecho
+
+import (
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns an Echo middleware for automatic instrumentation
+func Middleware(client *goinsight.Client, opts ...goinsight.MiddlewareOption) echo.MiddlewareFunc {
+	inst := client.NewServerInstrumentation(goinsight.MetricSource{
+		Language:  "go",
+		Framework: "echo",
+		Version:   echo.Version,
+	}, opts...)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(echoCtx echo.Context) error {
+			if inst.Skip(echoCtx.Request().URL.Path) {
+				return next(echoCtx)
+			}
+
+			start := time.Now()
+
+			// Start trace for this request
+			ctx, traceCtx := inst.Start(echoCtx.Request().Context(), echoCtx.Request().Method, echoCtx.Path())
+			if traceCtx != nil {
+				echoCtx.SetRequest(echoCtx.Request().WithContext(ctx))
+				echoCtx.Set("go-insight-trace", traceCtx)
+			}
+
+			// Process request
+			err := next(echoCtx)
+
+			// Get status code
+			statusCode := echoCtx.Response().Status
+			if statusCode == 0 {
+				statusCode = 200
+			}
+
+			// Echo pools its contexts, so Finish snapshots everything before
+			// we return
+			inst.Finish(echoCtx.Request().Context(), traceCtx, goinsight.CompletedRequest{
+				Method:         echoCtx.Request().Method,
+				Route:          echoCtx.Path(),
+				StatusCode:     statusCode,
+				Duration:       time.Since(start),
+				RequestHeader:  echoCtx.Request().Header,
+				ResponseHeader: echoCtx.Response().Header(),
+				Locals:         echoCtx.Get,
+			})
+
+			return err
+		}
+	}
+}
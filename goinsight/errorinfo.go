@@ -0,0 +1,192 @@
+package goinsight
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Metadata keys recorded for errors by LogError and Logger.Error
+const (
+	ErrorKey            = "error"
+	ErrorTypeKey        = "error_type"
+	ErrorChainKey       = "error_chain"
+	ErrorFingerprintKey = "error_fingerprint"
+	StacktraceKey       = "stacktrace"
+	CallerKey           = "caller"
+)
+
+// maxStackDepth bounds the frames recorded for one error
+const maxStackDepth = 32
+
+// fingerprintFrames is how many of the innermost frames a fingerprint
+// hashes: where the error was created or captured and its callers. Frames
+// further out, such as middleware, differ between routes reaching the same
+// failure.
+const fingerprintFrames = 3
+
+// sdkPackage prefixes the functions of this package, which are left out of
+// captured stacks
+const sdkPackage = "github.com/NathanSanchezDev/go-insight-go-sdk/goinsight."
+
+// StackFrame is one frame of a captured stack trace
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (f StackFrame) metadata() map[string]interface{} {
+	return map[string]interface{}{
+		"function": f.Function,
+		"file":     f.File,
+		"line":     f.Line,
+	}
+}
+
+// ErrorCause is one error of a chain, as walked by ErrorChain
+type ErrorCause struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// ErrorChain returns err and every error it wraps, depth first, following
+// both Unwrap() error and the Unwrap() []error of errors.Join
+func ErrorChain(err error) []ErrorCause {
+	var chain []ErrorCause
+	walkErrors(err, func(e error) {
+		chain = append(chain, ErrorCause{Type: errorType(e), Message: e.Error()})
+	})
+	return chain
+}
+
+func walkErrors(err error, visit func(error)) {
+	if err == nil {
+		return
+	}
+	visit(err)
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		walkErrors(u.Unwrap(), visit)
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			walkErrors(e, visit)
+		}
+	}
+}
+
+func errorType(err error) string {
+	return fmt.Sprintf("%T", err)
+}
+
+// ErrorStack returns the stack recorded by the innermost error in err's
+// chain that has one, such as errors created by github.com/pkg/errors. Any
+// StackTrace method returning a slice of program counters is understood.
+func ErrorStack(err error) []StackFrame {
+	var pcs []uintptr
+	walkErrors(err, func(e error) {
+		if found := stackPCs(e); found != nil {
+			pcs = found
+		}
+	})
+	if pcs == nil {
+		return nil
+	}
+	return framesOf(pcs)
+}
+
+// stackPCs reads the program counters of an error with a StackTrace
+// method, whose result type isn't known here: pkg/errors returns a
+// StackTrace of Frames, both defined on uintptr
+func stackPCs(err error) []uintptr {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+	out := method.Type().Out(0)
+	if out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+
+	trace := method.Call(nil)[0]
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+	return pcs
+}
+
+// callerStack captures the stack of the code calling into the SDK
+func callerStack() []StackFrame {
+	pcs := make([]uintptr, maxStackDepth+8)
+	n := runtime.Callers(2, pcs)
+	return framesOf(pcs[:n])
+}
+
+// framesOf resolves pcs, leaving out the SDK's own frames
+func framesOf(pcs []uintptr) []StackFrame {
+	var stack []StackFrame
+	frames := runtime.CallersFrames(pcs)
+	for len(stack) < maxStackDepth {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, sdkPackage) {
+			stack = append(stack, StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// ErrorFingerprint groups occurrences of the same failure: it hashes the
+// types in err's chain and the functions of the innermost three frames of
+// stack, so it is stable across messages, line shifts, deploys and the
+// middleware a request passed through. A nil stack fingerprints the chain
+// only.
+func ErrorFingerprint(err error, stack []StackFrame) string {
+	h := sha256.New()
+	walkErrors(err, func(e error) {
+		fmt.Fprintln(h, errorType(e))
+	})
+	if len(stack) > fingerprintFrames {
+		stack = stack[:fingerprintFrames]
+	}
+	for _, frame := range stack {
+		fmt.Fprintln(h, frame.Function)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// addErrorFields records err in metadata: its message, type, chain, stack,
+// fingerprint and the caller's frame. The stack comes from the error when
+// it carries one, otherwise from the caller.
+func addErrorFields(metadata map[string]interface{}, err error) {
+	caller := callerStack()
+	stack := ErrorStack(err)
+	if stack == nil {
+		stack = caller
+	}
+
+	chain := ErrorChain(err)
+	causes := make([]interface{}, len(chain))
+	for i, cause := range chain {
+		causes[i] = map[string]interface{}{"type": cause.Type, "message": cause.Message}
+	}
+	frames := make([]interface{}, len(stack))
+	for i, frame := range stack {
+		frames[i] = frame.metadata()
+	}
+
+	metadata[ErrorKey] = err.Error()
+	metadata[ErrorTypeKey] = errorType(err)
+	metadata[ErrorChainKey] = causes
+	metadata[StacktraceKey] = frames
+	metadata[ErrorFingerprintKey] = ErrorFingerprint(err, stack)
+	if len(caller) > 0 {
+		metadata[CallerKey] = caller[0].metadata()
+	}
+}
@@ -0,0 +1,187 @@
+package goinsight_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+)
+
+// These tests live outside the package: stacks leave out the package's own
+// frames, which would include tests inside it.
+
+const sdkPrefix = "github.com/NathanSanchezDev/go-insight-go-sdk/goinsight."
+
+// stackError records the stack where it was created, like pkg/errors
+type stackError struct {
+	msg string
+	pcs []uintptr
+}
+
+func newStackError(msg string) error {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	return &stackError{msg: msg, pcs: pcs[:n]}
+}
+
+func (e *stackError) Error() string         { return e.msg }
+func (e *stackError) StackTrace() []uintptr { return e.pcs }
+
+func loadOrderFromCart() error {
+	return fmt.Errorf("load order: %w", newStackError("timeout"))
+}
+
+func loadOrderFromCheckout() error {
+	return fmt.Errorf("load order: %w", newStackError("timeout"))
+}
+
+// handleOrder fails in loadOrderFromCart, two frames below it
+func handleOrder() error {
+	return serveOrder()
+}
+
+func serveOrder() error {
+	return loadOrderFromCart()
+}
+
+func withAuth(next func() error) error    { return next() }
+func withLogging(next func() error) error { return next() }
+
+func TestErrorChain(t *testing.T) {
+	inner := &fs.PathError{Op: "open", Path: "/etc/app.yaml", Err: fs.ErrNotExist}
+	err := fmt.Errorf("start: %w", errors.Join(inner, context.Canceled))
+
+	var got []string
+	for _, cause := range goinsight.ErrorChain(err) {
+		got = append(got, cause.Type+": "+cause.Message)
+	}
+	want := []string{
+		"*fmt.wrapError: start: open /etc/app.yaml: file does not exist\ncontext canceled",
+		"*errors.joinError: open /etc/app.yaml: file does not exist\ncontext canceled",
+		"*fs.PathError: open /etc/app.yaml: file does not exist",
+		"*errors.errorString: file does not exist",
+		"*errors.errorString: context canceled",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ErrorChain =\n%q\nwant\n%q", got, want)
+	}
+	if chain := goinsight.ErrorChain(nil); chain != nil {
+		t.Errorf("ErrorChain(nil) = %v", chain)
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	stack := goinsight.ErrorStack(fmt.Errorf("wrapped: %w", loadOrderFromCart()))
+	if len(stack) == 0 || !strings.HasSuffix(stack[0].Function, ".loadOrderFromCart") {
+		t.Fatalf("stack = %+v, want it to start at loadOrderFromCart", stack)
+	}
+	if !strings.HasSuffix(stack[0].File, "errorinfo_test.go") || stack[0].Line == 0 {
+		t.Errorf("frame = %+v", stack[0])
+	}
+
+	if stack := goinsight.ErrorStack(errors.New("plain")); stack != nil {
+		t.Errorf("ErrorStack of an error without a stack = %+v", stack)
+	}
+}
+
+func TestErrorStackLeavesOutSDKFrames(t *testing.T) {
+	client, err := goinsight.NewClient(
+		goinsight.WithEndpoint("http://127.0.0.1:1"),
+		goinsight.WithAPIKey("test"),
+		goinsight.WithServiceName("errorinfo-test"),
+		goinsight.WithSampler(goinsight.NeverSample()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// The handler runs below ProcessMessage, so its raw stack has SDK frames
+	captured := client.ProcessMessage(context.Background(), goinsight.MapCarrier{}, "test", nil, func(context.Context) error {
+		return newStackError("handler failed")
+	})
+
+	var raw []string
+	frames := runtime.CallersFrames(captured.(*stackError).pcs)
+	for more := true; more; {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+		raw = append(raw, frame.Function)
+	}
+	if !containsPrefix(raw, sdkPrefix) {
+		t.Fatalf("raw stack has no SDK frames: %v", raw)
+	}
+
+	var functions []string
+	for _, frame := range goinsight.ErrorStack(captured) {
+		functions = append(functions, frame.Function)
+	}
+	if containsPrefix(functions, sdkPrefix) {
+		t.Errorf("stack kept SDK frames: %v", functions)
+	}
+	if !containsPrefix(functions, "github.com/NathanSanchezDev/go-insight-go-sdk/goinsight_test.TestErrorStackLeavesOutSDKFrames") {
+		t.Errorf("stack lost the caller's frames: %v", functions)
+	}
+}
+
+func containsPrefix(list []string, prefix string) bool {
+	for _, s := range list {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	fingerprint := func(err error) string {
+		return goinsight.ErrorFingerprint(err, goinsight.ErrorStack(err))
+	}
+
+	var repeats []string
+	for i := 0; i < 3; i++ {
+		repeats = append(repeats, fingerprint(loadOrderFromCart()))
+	}
+	if repeats[0] == "" || repeats[1] != repeats[0] || repeats[2] != repeats[0] {
+		t.Errorf("repeats from one call site = %v, want one fingerprint", repeats)
+	}
+
+	if other := fingerprint(loadOrderFromCheckout()); other == repeats[0] {
+		t.Error("two call sites share a fingerprint")
+	}
+
+	// Messages don't matter, the chain's types do
+	err := loadOrderFromCart()
+	stack := goinsight.ErrorStack(err)
+	reworded := fmt.Errorf("fetch order 42: %w", errors.Unwrap(err))
+	if goinsight.ErrorFingerprint(reworded, stack) != goinsight.ErrorFingerprint(err, stack) {
+		t.Error("a different message changed the fingerprint")
+	}
+	if goinsight.ErrorFingerprint(errors.Unwrap(err), stack) == goinsight.ErrorFingerprint(err, stack) {
+		t.Error("a different chain kept the fingerprint")
+	}
+
+	// Without a stack only the chain counts
+	if goinsight.ErrorFingerprint(errors.New("a"), nil) != goinsight.ErrorFingerprint(errors.New("b"), nil) {
+		t.Error("stackless errors of one type differ")
+	}
+}
+
+func TestErrorFingerprintIgnoresOuterFrames(t *testing.T) {
+	fingerprint := func(err error) string {
+		return goinsight.ErrorFingerprint(err, goinsight.ErrorStack(err))
+	}
+
+	direct := fingerprint(handleOrder())
+	viaAuth := fingerprint(withAuth(handleOrder))
+	viaBoth := fingerprint(withLogging(func() error { return withAuth(handleOrder) }))
+	if direct != viaAuth || direct != viaBoth {
+		t.Errorf("fingerprints %s, %s, %s differ by middleware", direct, viaAuth, viaBoth)
+	}
+}
@@ -0,0 +1,132 @@
+package goinsight
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoTraceContext is returned when an operation needs a trace but ctx carries none
+	ErrNoTraceContext = errors.New("goinsight: no trace context found")
+
+	// ErrClientClosed is returned by every send after Close has been called
+	ErrClientClosed = errors.New("goinsight: client is closed")
+
+	// ErrQueueFull is returned when batching is enabled and a log or metric
+	// is dropped because the export queue is full
+	ErrQueueFull = errors.New("goinsight: export queue is full")
+
+	// ErrCircuitOpen is returned for telemetry dropped without being sent
+	// because the circuit breaker is open
+	ErrCircuitOpen = errors.New("goinsight: circuit breaker is open")
+)
+
+// maxErrorBody caps how much of an error response is read into an APIError
+const maxErrorBody = 64 << 10
+
+// APIError is returned when Go-Insight answers with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Message    string
+	Method     string
+	Path       string
+	Retryable  bool
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("goinsight: %s %s failed with status %d", e.Method, e.Path, e.StatusCode)
+	}
+	return fmt.Sprintf("goinsight: %s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// ResponseError is returned when Go-Insight answers with a 2xx status but a
+// body the SDK cannot use
+type ResponseError struct {
+	Method string
+	Path   string
+	Reason string
+	Err    error
+}
+
+func (e *ResponseError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("goinsight: %s %s returned an invalid response: %s: %v", e.Method, e.Path, e.Reason, e.Err)
+	}
+	return fmt.Sprintf("goinsight: %s %s returned an invalid response: %s", e.Method, e.Path, e.Reason)
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// newAPIError builds an APIError from a failed response, consuming its body
+func newAPIError(method, path string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    errorMessage(body),
+		Method:     method,
+		Path:       path,
+		Retryable:  retryableStatus(resp.StatusCode),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// errorMessage extracts the server's message from a JSON error body, falling
+// back to the raw body text
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		if payload.Error != "" {
+			return payload.Error
+		}
+		if payload.Message != "" {
+			return payload.Message
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// retryableStatus reports whether a request that failed with status may
+// succeed when sent again
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return status >= 500
+}
+
+// parseRetryAfter accepts both forms of the Retry-After header: delay
+// seconds and an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,207 @@
+package goinsight
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Exporter delivers telemetry to a backend. The client calls it after
+// sampling, processors and redaction. Traces and spans get their IDs from the exporter.
+// Implementations must be safe for concurrent use and should bound each
+// call themselves; the client only passes the caller's context along.
+type Exporter interface {
+	ExportLog(ctx context.Context, entry LogEntry) error
+	ExportMetric(ctx context.Context, metric Metric) error
+	StartTrace(ctx context.Context, trace Trace) (traceID string, err error)
+	StartSpan(ctx context.Context, span Span) (spanID string, err error)
+	EndSpan(ctx context.Context, spanID string, end SpanEnd) error
+	EndTrace(ctx context.Context, traceID string) error
+	ExportError(ctx context.Context, event ErrorEvent) error
+
+	// Shutdown releases the exporter's resources when the client closes
+	Shutdown(ctx context.Context) error
+}
+
+// maxRetryDelay caps the wait between retries
+const maxRetryDelay = 30 * time.Second
+
+// httpExporter sends telemetry to the Go-Insight HTTP API. It is the
+// default exporter.
+type httpExporter struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+	timeout  time.Duration
+	retries  int
+	backoff  time.Duration
+	stats    *clientStats
+
+	// noErrorsAPI is set once the server turns out not to have /errors
+	noErrorsAPI atomic.Bool
+}
+
+var _ Exporter = (*httpExporter)(nil)
+
+func (e *httpExporter) ExportLog(ctx context.Context, entry LogEntry) error {
+	return e.sendRequest(ctx, "POST", "/logs", entry)
+}
+
+func (e *httpExporter) ExportMetric(ctx context.Context, metric Metric) error {
+	return e.sendRequest(ctx, "POST", "/metrics", metric)
+}
+
+func (e *httpExporter) StartTrace(ctx context.Context, trace Trace) (string, error) {
+	return e.sendCreate(ctx, "/traces", trace)
+}
+
+func (e *httpExporter) StartSpan(ctx context.Context, span Span) (string, error) {
+	return e.sendCreate(ctx, "/spans", span)
+}
+
+func (e *httpExporter) EndSpan(ctx context.Context, spanID string, end SpanEnd) error {
+	return e.sendRequest(ctx, "POST", fmt.Sprintf("/spans/%s/end", spanID), end)
+}
+
+func (e *httpExporter) EndTrace(ctx context.Context, traceID string) error {
+	return e.sendRequest(ctx, "POST", fmt.Sprintf("/traces/%s/end", traceID), nil)
+}
+
+// ExportError posts the event to /errors. A server without error tracking
+// answers 404 or 405; the event, and every later one, is then sent as an
+// error log instead.
+func (e *httpExporter) ExportError(ctx context.Context, event ErrorEvent) error {
+	if !e.noErrorsAPI.Load() {
+		err := e.sendRequest(ctx, "POST", "/errors", event)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || (apiErr.StatusCode != http.StatusNotFound && apiErr.StatusCode != http.StatusMethodNotAllowed) {
+			return err
+		}
+		e.noErrorsAPI.Store(true)
+	}
+	return e.ExportLog(ctx, event.logEntry())
+}
+
+func (e *httpExporter) Shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// sendCreate posts a new resource and returns the ID Go-Insight assigned to it
+func (e *httpExporter) sendCreate(ctx context.Context, path string, data interface{}) (string, error) {
+	var resp createResponse
+	if err := e.sendRequestWithResponse(ctx, "POST", path, data, &resp); err != nil {
+		return "", err
+	}
+	if err := resp.validate("POST", path); err != nil {
+		return "", err
+	}
+	return string(resp.ID), nil
+}
+
+func (e *httpExporter) sendRequest(ctx context.Context, method, path string, data interface{}) error {
+	return e.sendRequestWithResponse(ctx, method, path, data, nil)
+}
+
+func (e *httpExporter) sendRequestWithResponse(ctx context.Context, method, path string, data interface{}, response interface{}) error {
+	for attempt := 0; ; attempt++ {
+		err := e.transmit(ctx, method, path, data, response)
+		if err == nil || attempt >= e.retries || !retryable(ctx, err) {
+			return err
+		}
+		e.stats.retry()
+
+		timer := time.NewTimer(e.retryDelay(attempt, err))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// retryable reports whether a failed request may succeed when sent again
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable
+	}
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return false
+	}
+	// Network failures and per-attempt timeouts
+	return errors.Is(err, errSend)
+}
+
+// retryDelay returns the exponential backoff before retry attempt+1, or the
+// server's Retry-After when that is longer
+func (e *httpExporter) retryDelay(attempt int, err error) time.Duration {
+	delay := e.backoff << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+		delay = min(apiErr.RetryAfter, maxRetryDelay)
+	}
+	return delay
+}
+
+// errSend marks failures to get any response, which are worth retrying
+var errSend = errors.New("failed to send request")
+
+// transmit sends one request, bounded by the exporter timeout. Background
+// exports such as batches, summaries and stats rely on it and pass
+// context.Background().
+func (e *httpExporter) transmit(ctx context.Context, method, path string, data interface{}, response interface{}) error {
+	var body []byte
+	var err error
+
+	if data != nil {
+		body, err = json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	ctx, cancel := requestContext(ctx, e.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, e.endpoint+path, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", e.apiKey)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errSend, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(method, path, resp)
+	}
+
+	if response != nil {
+		if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+			return &ResponseError{Method: method, Path: path, Reason: "failed to decode response", Err: err}
+		}
+	}
+
+	return nil
+}
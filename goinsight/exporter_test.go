@@ -0,0 +1,145 @@
+package goinsight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingExporter keeps the logs, spans and error events it is given, in
+// order
+type recordingExporter struct {
+	mu     sync.Mutex
+	logs   []LogEntry
+	spans  []Span
+	ends   map[string]SpanEnd
+	events []ErrorEvent
+}
+
+func (e *recordingExporter) ExportMetric(context.Context, Metric) error { return nil }
+func (e *recordingExporter) EndTrace(context.Context, string) error     { return nil }
+func (e *recordingExporter) Shutdown(context.Context) error             { return nil }
+
+func (e *recordingExporter) ExportError(_ context.Context, event ErrorEvent) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+	return nil
+}
+
+func (e *recordingExporter) ExportLog(_ context.Context, entry LogEntry) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.logs = append(e.logs, entry)
+	return nil
+}
+
+func (e *recordingExporter) StartTrace(context.Context, Trace) (string, error) {
+	return "trace-1", nil
+}
+
+func (e *recordingExporter) StartSpan(_ context.Context, span Span) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+	return fmt.Sprintf("span-%d", len(e.spans)), nil
+}
+
+func (e *recordingExporter) EndSpan(_ context.Context, spanID string, end SpanEnd) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.ends == nil {
+		e.ends = make(map[string]SpanEnd)
+	}
+	e.ends[spanID] = end
+	return nil
+}
+
+// newHTTPClient returns a client sending to an httptest server running
+// handler, both closed when the test ends
+func newHTTPClient(t *testing.T, handler http.Handler, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(append([]Option{
+		WithEndpoint(srv.URL),
+		WithAPIKey("test"),
+		WithServiceName("exporter-test"),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// blockingHandler holds every request until release is called, which must
+// happen before the server closes
+func blockingHandler() (handler http.Handler, release func()) {
+	done := make(chan struct{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}), func() { close(done) }
+}
+
+func TestSendStopsOnCancel(t *testing.T) {
+	handler, release := blockingHandler()
+	defer release()
+	client := newHTTPClient(t, handler, WithTimeout(time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := client.SendMetricContext(ctx, Metric{Path: "/", Method: "GET"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("send returned after %v", elapsed)
+	}
+}
+
+func TestSendStopsOnTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		ctx  func() context.Context
+	}{
+		{
+			name: "client timeout",
+			opts: []Option{WithTimeout(50 * time.Millisecond)},
+			ctx:  context.Background,
+		},
+		{
+			name: "request timeout",
+			opts: []Option{WithTimeout(time.Minute)},
+			ctx: func() context.Context {
+				return WithRequestTimeout(context.Background(), 50*time.Millisecond)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, release := blockingHandler()
+			defer release()
+			client := newHTTPClient(t, handler, tt.opts...)
+
+			start := time.Now()
+			err := client.SendMetricContext(tt.ctx(), Metric{Path: "/", Method: "GET"})
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("send returned after %v", elapsed)
+			}
+		})
+	}
+}
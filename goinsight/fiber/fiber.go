@@ -0,0 +1,75 @@
+// Package goinsightfiber provides Go-Insight middleware for Fiber applications
+package goinsightfiber
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Middleware returns a Fiber middleware for automatic instrumentation.
+//
+// Fiber only resolves the matched route after the handler chain runs, so the
+// root span is named after the request path while metrics and logs use the
+// route pattern.
+func Middleware(client *goinsight.Client, opts ...goinsight.MiddlewareOption) fiber.Handler {
+	inst := client.NewServerInstrumentation(goinsight.MetricSource{
+		Language:  "go",
+		Framework: "fiber",
+		Version:   fiber.Version,
+	}, opts...)
+
+	return func(fiberCtx *fiber.Ctx) error {
+		// Fiber reuses the buffers behind its strings once the handler
+		// returns, so everything kept past this call is copied
+		path := utils.CopyString(fiberCtx.Path())
+		if inst.Skip(path) {
+			return fiberCtx.Next()
+		}
+
+		start := time.Now()
+		method := utils.CopyString(fiberCtx.Method())
+
+		// Start trace for this request
+		ctx, traceCtx := inst.Start(fiberCtx.UserContext(), method, path)
+		if traceCtx != nil {
+			fiberCtx.SetUserContext(ctx)
+			fiberCtx.Locals("go-insight-trace", traceCtx)
+		}
+
+		// Process request
+		err := fiberCtx.Next()
+
+		// The error handler writes the response after this middleware
+		// returns, so take the status from the error when there is one
+		statusCode := fiberCtx.Response().StatusCode()
+		if err != nil {
+			statusCode = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				statusCode = fiberErr.Code
+			}
+		}
+
+		inst.Finish(fiberCtx.UserContext(), traceCtx, goinsight.CompletedRequest{
+			Method:     method,
+			Route:      utils.CopyString(fiberCtx.Route().Path),
+			StatusCode: statusCode,
+			Duration:   time.Since(start),
+			RequestHeader: goinsight.HeaderFunc(func(key string) string {
+				return utils.CopyString(fiberCtx.Get(key))
+			}),
+			ResponseHeader: goinsight.HeaderFunc(func(key string) string {
+				return utils.CopyString(fiberCtx.GetRespHeader(key))
+			}),
+			Locals: func(key string) interface{} {
+				return fiberCtx.Locals(key)
+			},
+		})
+
+		return err
+	}
+}
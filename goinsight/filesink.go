@@ -0,0 +1,125 @@
+package goinsight
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink writes events as JSON lines to a file, rotating it by size:
+// when a write would grow it past maxSize, path is renamed to path.1,
+// path.1 to path.2 and so on, keeping maxFiles rotated files
+type FileSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	mu     sync.Mutex
+	file   *os.File // nil after a failed rotation, until reopened
+	size   int64
+	closed bool
+}
+
+// Default FileSink limits
+const (
+	DefaultSinkMaxSize  = 10 << 20
+	DefaultSinkMaxFiles = 5
+)
+
+// NewFileSink opens path for appending, creating it if needed. A maxSize
+// or maxFiles of zero uses DefaultSinkMaxSize and DefaultSinkMaxFiles.
+func NewFileSink(path string, maxSize int64, maxFiles int) (*FileSink, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultSinkMaxSize
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultSinkMaxFiles
+	}
+
+	s := &FileSink{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("goinsight: open sink file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("goinsight: open sink file: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) Write(event SinkEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return os.ErrClosed
+	}
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+// rotate shifts the rotated files up by one and starts a new file. If the
+// new file can't be opened, the next write tries again.
+func (s *FileSink) rotate() error {
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return err
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", s.path, s.maxFiles))
+	for i := s.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		// Keep appending to the current file rather than losing events
+		if openErr := s.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("goinsight: rotate sink file: %w", err)
+	}
+	return s.open()
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
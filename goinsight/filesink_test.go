@@ -0,0 +1,151 @@
+package goinsight
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sinkLine is what FileSink writes for event
+func sinkLine(t *testing.T, event SinkEvent) int64 {
+	t.Helper()
+	line, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int64(len(line)) + 1
+}
+
+func testEvent(message string) SinkEvent {
+	return SinkEvent{
+		Kind:   SinkLog,
+		Time:   time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Record: LogEntry{LogLevel: "INFO", Message: message},
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestFileSinkRotatesAtMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	line := sinkLine(t, testEvent("order 1"))
+
+	// Room for two lines, not three
+	sink, err := NewFileSink(path, 2*line+1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	for _, message := range []string{"order 1", "order 2"} {
+		if err := sink.Write(testEvent(message)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("rotated below the limit: %v", err)
+	}
+
+	if err := sink.Write(testEvent("order 3")); err != nil {
+		t.Fatal(err)
+	}
+	if size := fileSize(t, path+".1"); size != 2*line {
+		t.Errorf("rotated file has %d bytes, want the first two lines", size)
+	}
+	if size := fileSize(t, path); size != line {
+		t.Errorf("new file has %d bytes, want the third line", size)
+	}
+}
+
+func TestFileSinkKeepsMaxFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.log")
+
+	// Every write after the first rotates
+	sink, err := NewFileSink(path, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	for _, message := range []string{"order 1", "order 2", "order 3", "order 4"} {
+		if err := sink.Write(testEvent(message)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if got := strings.Join(names, " "); got != "events.log events.log.1 events.log.2" {
+		t.Errorf("files %q, want the current one and two rotated", got)
+	}
+	for file, message := range map[string]string{path: "order 4", path + ".1": "order 3", path + ".2": "order 2"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), message) {
+			t.Errorf("%s = %s, want %q", filepath.Base(file), data, message)
+		}
+	}
+}
+
+func TestFileSinkReopensAfterFailedRotation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sink")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "events.log")
+
+	sink, err := NewFileSink(path, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Write(testEvent("order 1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without its directory the file can be neither rotated nor reopened
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(testEvent("order 2")); err == nil {
+		t.Fatal("rotation into a missing directory succeeded")
+	}
+
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(testEvent("order 3")); err != nil {
+		t.Fatalf("write after the directory came back: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "order 3") {
+		t.Errorf("file = %s, want order 3", data)
+	}
+
+	sink.Close()
+	if err := sink.Write(testEvent("order 4")); err != os.ErrClosed {
+		t.Errorf("write after Close = %v, want %v", err, os.ErrClosed)
+	}
+}
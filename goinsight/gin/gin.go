@@ -0,0 +1,49 @@
+// Package goinsightgin provides Go-Insight middleware for Gin
+package goinsightgin
+
+import (
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware returns a Gin middleware for automatic instrumentation
+func Middleware(client *goinsight.Client, opts ...goinsight.MiddlewareOption) gin.HandlerFunc {
+	inst := client.NewServerInstrumentation(goinsight.MetricSource{
+		Language:  "go",
+		Framework: "gin",
+		Version:   gin.Version,
+	}, opts...)
+
+	return func(ginCtx *gin.Context) {
+		if inst.Skip(ginCtx.Request.URL.Path) {
+			ginCtx.Next()
+			return
+		}
+
+		start := time.Now()
+
+		// Start trace for this request
+		ctx, traceCtx := inst.Start(ginCtx.Request.Context(), ginCtx.Request.Method, ginCtx.FullPath())
+		if traceCtx != nil {
+			ginCtx.Request = ginCtx.Request.WithContext(ctx)
+			ginCtx.Set("go-insight-trace", traceCtx)
+		}
+
+		// Process request
+		ginCtx.Next()
+
+		// Gin recycles ginCtx for the next request, so Finish snapshots
+		// everything before we return
+		inst.Finish(ginCtx.Request.Context(), traceCtx, goinsight.CompletedRequest{
+			Method:         ginCtx.Request.Method,
+			Route:          ginCtx.FullPath(),
+			StatusCode:     ginCtx.Writer.Status(),
+			Duration:       time.Since(start),
+			RequestHeader:  ginCtx.Request.Header,
+			ResponseHeader: ginCtx.Writer.Header(),
+			Locals:         func(key string) interface{} { v, _ := ginCtx.Get(key); return v },
+		})
+	}
+}
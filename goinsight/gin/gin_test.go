@@ -0,0 +1,144 @@
+package goinsightgin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/gin-gonic/gin"
+)
+
+// backend is a fake Go-Insight API recording the metrics it receives
+type backend struct {
+	ids atomic.Int64
+
+	mu      sync.Mutex
+	metrics []goinsight.Metric
+}
+
+func (b *backend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/metrics":
+		var m goinsight.Metric
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b.mu.Lock()
+		b.metrics = append(b.metrics, m)
+		b.mu.Unlock()
+	case r.URL.Path == "/traces" || r.URL.Path == "/spans":
+		fmt.Fprintf(w, `{"id":"%d"}`, b.ids.Add(1))
+		return
+	case strings.HasSuffix(r.URL.Path, "/end"), r.URL.Path == "/logs":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (b *backend) received() []goinsight.Metric {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]goinsight.Metric(nil), b.metrics...)
+}
+
+// TestMiddlewareConcurrentRequests checks that every metric reports the
+// route and status of its own request while Gin recycles its contexts.
+// Run it with -race.
+func TestMiddlewareConcurrentRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	api := &backend{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	client, err := goinsight.NewClient(
+		goinsight.WithEndpoint(srv.URL),
+		goinsight.WithAPIKey("test"),
+		goinsight.WithServiceName("gin-test"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	routes := []struct {
+		route  string
+		status int
+	}{
+		{"/ok/:id", http.StatusOK},
+		{"/created/:id", http.StatusCreated},
+		{"/missing/:id", http.StatusNotFound},
+		{"/fail/:id", http.StatusInternalServerError},
+	}
+
+	router := gin.New()
+	router.Use(Middleware(client))
+	for _, r := range routes {
+		status := r.status
+		router.GET(r.route, func(c *gin.Context) {
+			c.Status(status)
+		})
+	}
+	app := httptest.NewServer(router)
+	defer app.Close()
+
+	const requests = 200
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			r := routes[i%len(routes)]
+			url := app.URL + strings.Replace(r.route, ":id", fmt.Sprint(i), 1)
+			req, _ := http.NewRequest(http.MethodGet, url, nil)
+			req.Header.Set("X-Request-ID", fmt.Sprint(i))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}(i)
+	}
+	wg.Wait()
+
+	// Metrics are exported asynchronously
+	deadline := time.Now().Add(10 * time.Second)
+	for len(api.received()) < requests && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	metrics := api.received()
+	if len(metrics) != requests {
+		t.Fatalf("received %d metrics, want %d", len(metrics), requests)
+	}
+	seen := make(map[string]bool, requests)
+	for _, m := range metrics {
+		var i int
+		if _, err := fmt.Sscan(m.RequestID, &i); err != nil {
+			t.Fatalf("metric without request ID: %+v", m)
+		}
+		want := routes[i%len(routes)]
+		if m.Path != want.route || m.StatusCode != want.status || m.Method != http.MethodGet {
+			t.Errorf("request %d: got %s %s %d, want GET %s %d", i, m.Method, m.Path, m.StatusCode, want.route, want.status)
+		}
+		if seen[m.RequestID] {
+			t.Errorf("request %d reported twice", i)
+		}
+		seen[m.RequestID] = true
+		if m.Source.Framework != "gin" {
+			t.Errorf("request %d: framework %q, want gin", i, m.Source.Framework)
+		}
+	}
+}
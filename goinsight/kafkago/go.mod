@@ -0,0 +1,13 @@
+module github.com/NathanSanchezDev/go-insight-go-sdk/goinsight/kafkago
+
+go 1.21
+
+require (
+	github.com/NathanSanchezDev/go-insight-go-sdk v0.2.0
+	github.com/segmentio/kafka-go v0.4.47
+)
+
+require (
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+)
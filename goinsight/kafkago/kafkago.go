@@ -0,0 +1,114 @@
+// Package goinsightkafkago propagates Go-Insight traces through
+// segmentio/kafka-go messages
+package goinsightkafkago
+
+import (
+	"context"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/segmentio/kafka-go"
+)
+
+// Carrier adapts the headers of a kafka.Message to goinsight.Carrier
+type Carrier struct {
+	msg *kafka.Message
+}
+
+var _ goinsight.Carrier = Carrier{}
+
+// NewCarrier returns a Carrier over msg's headers
+func NewCarrier(msg *kafka.Message) Carrier {
+	return Carrier{msg: msg}
+}
+
+// Get returns the value of the first header named key
+func (c Carrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set replaces the header named key, or appends it
+func (c Carrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if h.Key == key {
+			// Copy before writing so a caller's shared slice isn't modified
+			headers := append([]kafka.Header(nil), c.msg.Headers...)
+			headers[i].Value = []byte(value)
+			c.msg.Headers = headers
+			return
+		}
+	}
+	// Full slice expression so append never writes into a caller's array
+	n := len(c.msg.Headers)
+	c.msg.Headers = append(c.msg.Headers[:n:n], kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Writer wraps a kafka.Writer, recording each WriteMessages call as a
+// "kafka.send" span and injecting the trace into every message
+//
+//	w := goinsightkafkago.NewWriter(client, &kafka.Writer{Addr: kafka.TCP("localhost:9092"), Topic: "orders"})
+type Writer struct {
+	*kafka.Writer
+	client *goinsight.Client
+}
+
+// NewWriter returns a Writer reporting to client
+func NewWriter(client *goinsight.Client, w *kafka.Writer) *Writer {
+	return &Writer{Writer: w, client: client}
+}
+
+// WriteMessages writes msgs with the trace context of ctx in their headers.
+// The headers go on copies, so the caller's messages can be written again
+// without carrying this span. Messages are only traced when ctx carries a
+// trace.
+func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	spanCtx, finish := w.client.StartProducerSpan(ctx, "kafka.send", map[string]interface{}{
+		"messaging.system":      "kafka",
+		"messaging.operation":   "send",
+		"messaging.destination": w.topic(msgs),
+		"messaging.batch_size":  len(msgs),
+	})
+
+	traced := make([]kafka.Message, len(msgs))
+	copy(traced, msgs)
+	for i := range traced {
+		goinsight.Inject(spanCtx, NewCarrier(&traced[i]))
+	}
+
+	err := w.Writer.WriteMessages(ctx, traced...)
+	finish(err)
+	return err
+}
+
+// topic names the destination of msgs, which is the writer's topic unless
+// each message sets its own
+func (w *Writer) topic(msgs []kafka.Message) string {
+	if w.Writer.Topic != "" {
+		return w.Writer.Topic
+	}
+	if len(msgs) > 0 {
+		return msgs[0].Topic
+	}
+	return ""
+}
+
+// Process runs fn for a message fetched from a kafka.Reader inside a
+// "kafka.process" span, recording its topic, partition and offset. The span
+// joins the producer's trace when msg carries one. Commit msg once Process
+// returns nil.
+//
+//	msg, err := r.FetchMessage(ctx)
+//	err = goinsightkafkago.Process(ctx, client, msg, handle)
+func Process(ctx context.Context, client *goinsight.Client, msg kafka.Message, fn func(ctx context.Context) error) error {
+	return client.ProcessMessage(ctx, NewCarrier(&msg), "kafka.process", map[string]interface{}{
+		"messaging.system":          "kafka",
+		"messaging.operation":       "process",
+		"messaging.destination":     msg.Topic,
+		"messaging.kafka.partition": msg.Partition,
+		"messaging.kafka.offset":    msg.Offset,
+	}, fn)
+}
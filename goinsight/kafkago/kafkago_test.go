@@ -0,0 +1,47 @@
+package goinsightkafkago
+
+import (
+	"testing"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestCarrierSetGet(t *testing.T) {
+	shared := make([]kafka.Header, 1, 4)
+	shared[0] = kafka.Header{Key: "tenant", Value: []byte("acme")}
+	msg := kafka.Message{Headers: shared}
+	carrier := NewCarrier(&msg)
+
+	carrier.Set(goinsight.TraceIDHeader, "trace-1")
+	if got := carrier.Get(goinsight.TraceIDHeader); got != "trace-1" {
+		t.Errorf("Get after Set = %q", got)
+	}
+	if got := carrier.Get("tenant"); got != "acme" {
+		t.Errorf("existing header = %q", got)
+	}
+	if got := carrier.Get("missing"); got != "" {
+		t.Errorf("missing header = %q", got)
+	}
+
+	carrier.Set(goinsight.TraceIDHeader, "trace-2")
+	if got := carrier.Get(goinsight.TraceIDHeader); got != "trace-2" {
+		t.Errorf("Get after overwrite = %q", got)
+	}
+	if len(msg.Headers) != 2 {
+		t.Errorf("overwrite appended: %d headers", len(msg.Headers))
+	}
+
+	// The caller's backing array has spare capacity; appending must not use it
+	if extra := shared[:2]; string(extra[1].Key) != "" {
+		t.Errorf("Set wrote into the caller's array: %+v", extra[1])
+	}
+
+	carrier.Set("tenant", "globex")
+	if string(shared[0].Value) != "acme" {
+		t.Errorf("overwrite modified the caller's slice: %q", shared[0].Value)
+	}
+	if got := carrier.Get("tenant"); got != "globex" {
+		t.Errorf("Get after overwrite = %q", got)
+	}
+}
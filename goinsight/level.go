@@ -0,0 +1,229 @@
+package goinsight
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Level is the severity of a log entry
+type Level int32
+
+// Log levels, from least to most severe
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the level as sent to Go-Insight, e.g. "INFO"
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int32(l))
+}
+
+// MarshalText encodes the level as its name
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a level name, see ParseLevel
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// ParseLevel parses a level name, ignoring case. "warning" is accepted for
+// LevelWarn.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN", "WARNING":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	}
+	return 0, fmt.Errorf("goinsight: unknown level %q", s)
+}
+
+// LevelVar is a level that can be changed while the client is in use. The
+// zero value is LevelDebug.
+type LevelVar struct {
+	v atomic.Int32
+}
+
+// Level returns the current level
+func (v *LevelVar) Level() Level {
+	return Level(v.v.Load())
+}
+
+// Set changes the level
+func (v *LevelVar) Set(level Level) {
+	v.v.Store(int32(level))
+}
+
+func (v *LevelVar) String() string {
+	return fmt.Sprintf("LevelVar(%s)", v.Level())
+}
+
+// levels holds the client's minimum level and the overrides of named
+// loggers. Overrides are copied on write so lookups never lock.
+type levels struct {
+	min       LevelVar
+	mu        sync.Mutex
+	overrides atomic.Pointer[map[string]Level]
+}
+
+// enabled reports whether a log at level from the named logger is sent.
+// The most specific override wins: "db.pool" falls back to "db", then to
+// the client's level.
+func (l *levels) enabled(name string, level Level) bool {
+	return level >= l.effective(name)
+}
+
+func (l *levels) effective(name string) Level {
+	if overrides := l.overrides.Load(); overrides != nil && name != "" {
+		for {
+			if level, ok := (*overrides)[name]; ok {
+				return level
+			}
+			i := strings.LastIndexByte(name, '.')
+			if i < 0 {
+				break
+			}
+			name = name[:i]
+		}
+	}
+	return l.min.Level()
+}
+
+func (l *levels) set(name string, level Level, remove bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next := make(map[string]Level)
+	if current := l.overrides.Load(); current != nil {
+		for k, v := range *current {
+			next[k] = v
+		}
+	}
+	if remove {
+		delete(next, name)
+	} else {
+		next[name] = level
+	}
+	l.overrides.Store(&next)
+}
+
+func (l *levels) snapshot() map[string]Level {
+	out := make(map[string]Level)
+	if current := l.overrides.Load(); current != nil {
+		for k, v := range *current {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// Level returns the client's minimum log level. Logs below it are not sent;
+// call Set on it to change the level at runtime.
+func (c *Client) Level() *LevelVar {
+	return &c.levels.min
+}
+
+// SetLoggerLevel overrides the minimum level of the named logger and of the
+// loggers below it, e.g. "db" also covers "db.pool"
+func (c *Client) SetLoggerLevel(name string, level Level) {
+	c.levels.set(name, level, false)
+}
+
+// ResetLoggerLevel removes the override of the named logger
+func (c *Client) ResetLoggerLevel(name string) {
+	c.levels.set(name, 0, true)
+}
+
+// LoggerLevels returns the current per-logger overrides
+func (c *Client) LoggerLevels() map[string]Level {
+	return c.levels.snapshot()
+}
+
+// levelEnabled reports whether a log with the given level name is sent.
+// Unknown level names are always sent.
+func (c *Client) levelEnabled(name, level string) bool {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return true
+	}
+	return c.levels.enabled(name, parsed)
+}
+
+// levelState is the body served and accepted by LevelHandler
+type levelState struct {
+	Level   Level            `json:"level"`
+	Loggers map[string]Level `json:"loggers"`
+}
+
+// LevelHandler serves the client's levels, usually mounted at
+// /debug/goinsight/level. GET returns them as JSON. PUT or POST with a
+// level parameter changes the client's level, or with a logger parameter
+// that logger's override; DELETE with a logger parameter removes it.
+// Parameters come from the query string or a form body. The handler has
+// no authentication, so expose it only on an internal listener.
+//
+//	curl -X PUT 'localhost:6060/debug/goinsight/level?logger=db&level=debug'
+func (c *Client) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := r.FormValue("logger")
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			level, err := ParseLevel(r.FormValue("level"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if logger != "" {
+				c.SetLoggerLevel(logger, level)
+			} else {
+				c.Level().Set(level)
+			}
+		case http.MethodDelete:
+			if logger == "" {
+				http.Error(w, "logger is required", http.StatusBadRequest)
+				return
+			}
+			c.ResetLoggerLevel(logger)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelState{
+			Level:   c.Level().Level(),
+			Loggers: c.LoggerLevels(),
+		})
+	})
+}
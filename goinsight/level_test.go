@@ -0,0 +1,119 @@
+package goinsight
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// loggedMessages returns the messages of the logs exporter received
+func loggedMessages(exporter *recordingExporter) []string {
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	var messages []string
+	for _, entry := range exporter.logs {
+		messages = append(messages, entry.Message)
+	}
+	return messages
+}
+
+func TestLevelChangesFilterAtRuntime(t *testing.T) {
+	exporter := &recordingExporter{}
+	client, err := NewClient(WithServiceName("level-test"), WithExporter(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	log := client.Logger()
+	db := log.Named("db").Named("pool")
+
+	client.Level().Set(LevelWarn)
+	log.Info(ctx, "info while warn")
+	log.Warn(ctx, "warn while warn")
+	db.Debug(ctx, "db debug while warn")
+
+	client.SetLoggerLevel("db", LevelDebug)
+	log.Info(ctx, "info with db override")
+	db.Debug(ctx, "db debug with db override")
+
+	client.ResetLoggerLevel("db")
+	client.Level().Set(LevelInfo)
+	log.Info(ctx, "info while info")
+	db.Debug(ctx, "db debug while info")
+
+	want := []string{"warn while warn", "db debug with db override", "info while info"}
+	if got := loggedMessages(exporter); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+	if db.Enabled(LevelDebug) || !db.Enabled(LevelInfo) {
+		t.Error("Enabled doesn't follow the client level")
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	client, err := NewClient(WithServiceName("level-test"), WithExporter(&recordingExporter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.Level().Set(LevelInfo)
+	handler := client.LevelHandler()
+
+	serve := func(method, target string) (int, levelState) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+
+		var state levelState
+		if rec.Code == http.StatusOK {
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("%s %s: Content-Type %q", method, target, ct)
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+				t.Fatalf("%s %s: %v", method, target, err)
+			}
+		}
+		return rec.Code, state
+	}
+
+	if code, state := serve(http.MethodGet, "/"); code != http.StatusOK || state.Level != LevelInfo || len(state.Loggers) != 0 {
+		t.Errorf("GET = %d %+v", code, state)
+	}
+	if code, state := serve(http.MethodPut, "/?level=warn"); code != http.StatusOK || state.Level != LevelWarn {
+		t.Errorf("PUT level = %d %+v", code, state)
+	}
+	if code, state := serve(http.MethodPut, "/?logger=db&level=DEBUG"); code != http.StatusOK || state.Loggers["db"] != LevelDebug {
+		t.Errorf("PUT logger = %d %+v", code, state)
+	}
+	if code, state := serve(http.MethodGet, "/"); state.Level != LevelWarn || state.Loggers["db"] != LevelDebug {
+		t.Errorf("GET after PUT = %d %+v", code, state)
+	}
+	if client.Level().Level() != LevelWarn || !client.Logger().Named("db").Enabled(LevelDebug) {
+		t.Error("PUT didn't change the client's levels")
+	}
+
+	if code, _ := serve(http.MethodPut, "/?level=loud"); code != http.StatusBadRequest {
+		t.Errorf("PUT bad level = %d, want 400", code)
+	}
+	if code, _ := serve(http.MethodPut, "/"); code != http.StatusBadRequest {
+		t.Errorf("PUT without level = %d, want 400", code)
+	}
+	if client.Level().Level() != LevelWarn {
+		t.Errorf("a rejected PUT changed the level to %s", client.Level().Level())
+	}
+
+	if code, _ := serve(http.MethodDelete, "/"); code != http.StatusBadRequest {
+		t.Errorf("DELETE without logger = %d, want 400", code)
+	}
+	if code, state := serve(http.MethodDelete, "/?logger=db"); code != http.StatusOK || len(state.Loggers) != 0 {
+		t.Errorf("DELETE logger = %d %+v", code, state)
+	}
+	if code, _ := serve(http.MethodPatch, "/"); code != http.StatusMethodNotAllowed {
+		t.Errorf("PATCH = %d, want 405", code)
+	}
+}
@@ -0,0 +1,115 @@
+package goinsight
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Logger sends logs on behalf of a named component, so its level can be
+// overridden with Client.SetLoggerLevel, carrying fields bound with With.
+// The name is recorded under the "logger" metadata key. A Logger is a small
+// value, cheap to copy and to derive per request:
+//
+//	log := client.Logger().Named("checkout").With("user_id", userID)
+//	log.Info(ctx, "order placed", "order_id", orderID, "items", len(items))
+type Logger struct {
+	client *Client
+	name   string
+	fields []interface{}
+}
+
+// Logger returns the client's root logger
+func (c *Client) Logger() Logger {
+	return Logger{client: c}
+}
+
+// Named returns a logger for a component below l, joining names with ".":
+// client.Logger().Named("db").Named("pool") is "db.pool"
+func (l Logger) Named(name string) Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	l.name = name
+	return l
+}
+
+// With returns a logger that adds the given key/value pairs to every log.
+// Keys are strings; per-call pairs override bound ones with the same key.
+func (l Logger) With(keysAndValues ...interface{}) Logger {
+	// Cap the slice so loggers derived from l never share appends
+	n := len(l.fields)
+	l.fields = append(l.fields[:n:n], keysAndValues...)
+	return l
+}
+
+// Name returns the logger's name, empty for the root logger
+func (l Logger) Name() string {
+	return l.name
+}
+
+// Enabled reports whether logs at level are sent, so callers can skip
+// building expensive fields
+func (l Logger) Enabled(level Level) bool {
+	return l.client.levels.enabled(l.name, level)
+}
+
+// Debug sends a debug log with optional key/value pairs
+func (l Logger) Debug(ctx context.Context, message string, keysAndValues ...interface{}) error {
+	return l.log(ctx, LevelDebug, message, nil, keysAndValues)
+}
+
+// Info sends an info log with optional key/value pairs
+func (l Logger) Info(ctx context.Context, message string, keysAndValues ...interface{}) error {
+	return l.log(ctx, LevelInfo, message, nil, keysAndValues)
+}
+
+// Warn sends a warning log with optional key/value pairs
+func (l Logger) Warn(ctx context.Context, message string, keysAndValues ...interface{}) error {
+	return l.log(ctx, LevelWarn, message, nil, keysAndValues)
+}
+
+// Error sends an error log with optional key/value pairs, recording err
+// like Client.LogError. err may be nil.
+func (l Logger) Error(ctx context.Context, message string, err error, keysAndValues ...interface{}) error {
+	return l.log(ctx, LevelError, message, err, keysAndValues)
+}
+
+// Log sends a log at level with optional key/value pairs
+func (l Logger) Log(ctx context.Context, level Level, message string, keysAndValues ...interface{}) error {
+	return l.log(ctx, level, message, nil, keysAndValues)
+}
+
+func (l Logger) log(ctx context.Context, level Level, message string, err error, keysAndValues []interface{}) error {
+	if !l.Enabled(level) {
+		return nil
+	}
+
+	metadata := make(map[string]interface{}, (len(l.fields)+len(keysAndValues))/2+1)
+	addFields(metadata, l.fields)
+	addFields(metadata, keysAndValues)
+	if l.name != "" {
+		metadata["logger"] = l.name
+	}
+	if err != nil {
+		addErrorFields(metadata, err)
+	}
+	return l.client.writeLog(ctx, time.Now(), level.String(), message, metadata)
+}
+
+// badKey records a value without a string key, like log/slog
+const badKey = "!BADKEY"
+
+// addFields adds key/value pairs to metadata. A non-string key, or a value
+// left without one, is recorded under "!BADKEY".
+func addFields(metadata map[string]interface{}, keysAndValues []interface{}) {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok || i+1 == len(keysAndValues) {
+			metadata[badKey] = fmt.Sprint(keysAndValues[i])
+			i--
+			continue
+		}
+		metadata[key] = keysAndValues[i+1]
+	}
+}
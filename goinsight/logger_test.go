@@ -0,0 +1,81 @@
+package goinsight
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// lastLog returns the metadata of the last log exporter received
+func lastLog(t *testing.T, exporter *recordingExporter) map[string]interface{} {
+	t.Helper()
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if len(exporter.logs) == 0 {
+		t.Fatal("no log was sent")
+	}
+	return exporter.logs[len(exporter.logs)-1].Metadata
+}
+
+func TestAddFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		keysAndValues []interface{}
+		want          map[string]interface{}
+	}{
+		{"pairs", []interface{}{"a", 1, "b", "two"}, map[string]interface{}{"a": 1, "b": "two"}},
+		{"odd count", []interface{}{"a", 1, "dangling"}, map[string]interface{}{"a": 1, badKey: "dangling"}},
+		{"non-string key", []interface{}{42, "a", 1}, map[string]interface{}{badKey: "42", "a": 1}},
+		{"single value", []interface{}{true}, map[string]interface{}{badKey: "true"}},
+		{"later pair wins", []interface{}{"a", 1, "a", 2}, map[string]interface{}{"a": 2}},
+		{"empty", nil, map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]interface{}{}
+			addFields(got, tt.keysAndValues)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addFields(%v) = %v, want %v", tt.keysAndValues, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoggerWith(t *testing.T) {
+	exporter := &recordingExporter{}
+	client, err := NewClient(WithServiceName("logger-test"), WithExporter(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	base := client.Logger().Named("checkout").With("tenant", "acme", "region", "eu")
+	alice := base.With("user", "alice")
+	bob := base.With("user", "bob")
+
+	alice.Info(ctx, "order placed", "region", "us", "items", 2)
+	want := map[string]interface{}{"tenant": "acme", "region": "us", "user": "alice", "items": 2, "logger": "checkout"}
+	if got := lastLog(t, exporter); !reflect.DeepEqual(got, want) {
+		t.Errorf("alice = %v, want %v", got, want)
+	}
+
+	// Loggers derived from the same parent don't see each other's fields
+	bob.Info(ctx, "order placed")
+	want = map[string]interface{}{"tenant": "acme", "region": "eu", "user": "bob", "logger": "checkout"}
+	if got := lastLog(t, exporter); !reflect.DeepEqual(got, want) {
+		t.Errorf("bob = %v, want %v", got, want)
+	}
+
+	base.Named("payments").Info(ctx, "charged")
+	want = map[string]interface{}{"tenant": "acme", "region": "eu", "logger": "checkout.payments"}
+	if got := lastLog(t, exporter); !reflect.DeepEqual(got, want) {
+		t.Errorf("named child = %v, want %v", got, want)
+	}
+
+	base.With("dangling").Info(ctx, "odd fields")
+	if got := lastLog(t, exporter); got[badKey] != "dangling" || got["tenant"] != "acme" {
+		t.Errorf("odd With = %v", got)
+	}
+}
@@ -0,0 +1,181 @@
+package goinsight
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// MiddlewareOption configures the framework middlewares
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct {
+	skipPaths       map[string]struct{}
+	skipPatterns    []*regexp.Regexp
+	spanName        func(method, route string) string
+	requestHeaders  []string
+	responseHeaders []string
+	userID          func(ctx context.Context) string
+	statusLevel     func(statusCode int) string
+}
+
+func newMiddlewareConfig(opts []MiddlewareOption) *middlewareConfig {
+	cfg := &middlewareConfig{
+		skipPaths:   make(map[string]struct{}),
+		spanName:    defaultSpanName,
+		statusLevel: defaultStatusLevel,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithSkipPaths disables instrumentation for requests whose URL path exactly
+// matches one of paths, such as health checks and metric scrapes
+func WithSkipPaths(paths ...string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		for _, path := range paths {
+			cfg.skipPaths[path] = struct{}{}
+		}
+	}
+}
+
+// WithSkipPathPatterns disables instrumentation for requests whose URL path
+// matches any of patterns
+func WithSkipPathPatterns(patterns ...*regexp.Regexp) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.skipPatterns = append(cfg.skipPatterns, patterns...)
+	}
+}
+
+// WithSpanNameFormatter overrides how the root span of a request is named.
+// The default is "METHOD route".
+func WithSpanNameFormatter(format func(method, route string) string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		if format != nil {
+			cfg.spanName = format
+		}
+	}
+}
+
+// WithRequestHeaders records the named request headers in the request log.
+// Only the listed headers are captured, and the values of those matching
+// DefaultRedactKeys, such as Authorization and Cookie, are redacted.
+func WithRequestHeaders(names ...string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.requestHeaders = appendCanonical(cfg.requestHeaders, names)
+	}
+}
+
+// WithResponseHeaders records the named response headers in the request log.
+// Only the listed headers are captured, and the values of those matching
+// DefaultRedactKeys, such as Set-Cookie, are redacted.
+func WithResponseHeaders(names ...string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.responseHeaders = appendCanonical(cfg.responseHeaders, names)
+	}
+}
+
+// WithUserIDExtractor records the user returned by extract in the request
+// log. It runs after the handler, so it sees values stored by authentication
+// middleware; string keys also resolve through the router's request-local
+// storage (gin.Context.Set, echo.Context.Set, fiber.Ctx.Locals).
+func WithUserIDExtractor(extract func(ctx context.Context) string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.userID = extract
+	}
+}
+
+// WithStatusLevel overrides the log level used for a response status. The
+// default logs 4xx and 5xx as ERROR, 3xx as WARN and everything else as INFO.
+func WithStatusLevel(level func(statusCode int) string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		if level != nil {
+			cfg.statusLevel = level
+		}
+	}
+}
+
+// skip reports whether requests to path should not be instrumented
+func (cfg *middlewareConfig) skip(path string) bool {
+	if _, ok := cfg.skipPaths[path]; ok {
+		return true
+	}
+	for _, pattern := range cfg.skipPatterns {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerRedactor redacts credentials in captured headers whether or not
+// the client has a redactor
+var headerRedactor = NewRedactor(RedactKeys(DefaultRedactKeys...))
+
+// captureHeaders copies the allow-listed headers present in h, redacting
+// the values of deny-listed ones
+func captureHeaders(names []string, h HeaderGetter) map[string]string {
+	if len(names) == 0 || h == nil {
+		return nil
+	}
+
+	captured := make(map[string]string, len(names))
+	for _, name := range names {
+		value := h.Get(name)
+		if value == "" {
+			continue
+		}
+		if headerRedactor.deniedKey(name) {
+			value = headerRedactor.strategy(value)
+		}
+		captured[name] = value
+	}
+	return captured
+}
+
+// extractUserID runs the configured extractor, if any
+func (cfg *middlewareConfig) extractUserID(ctx context.Context) string {
+	if cfg.userID == nil {
+		return ""
+	}
+	return cfg.userID(ctx)
+}
+
+func appendCanonical(dst []string, names []string) []string {
+	for _, name := range names {
+		dst = append(dst, http.CanonicalHeaderKey(name))
+	}
+	return dst
+}
+
+func defaultSpanName(method, route string) string {
+	return fmt.Sprintf("%s %s", method, route)
+}
+
+func defaultStatusLevel(statusCode int) string {
+	if statusCode >= 400 {
+		return "ERROR"
+	} else if statusCode >= 300 {
+		return "WARN"
+	}
+	return "INFO"
+}
+
+// localsContext resolves string keys through a router's request-local
+// storage before falling back to the request context
+type localsContext struct {
+	context.Context
+	get func(key string) interface{}
+}
+
+func (l localsContext) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		if v := l.get(k); v != nil {
+			return v
+		}
+	}
+	return l.Context.Value(key)
+}
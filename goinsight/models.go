@@ -1,6 +1,11 @@
 package goinsight
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // Config holds the configuration for the Go-Insight client
 type Config struct {
@@ -8,6 +13,96 @@ type Config struct {
 	Endpoint    string
 	ServiceName string
 	Timeout     time.Duration
+
+	// ServiceVersion and Environment are recorded as resource attributes.
+	// They default to the main module version and GOINSIGHT_ENV.
+	ServiceVersion string
+	Environment    string
+
+	// ResourceAttributes are merged into the detected resource, overriding
+	// detected values. See DetectResource.
+	ResourceAttributes map[string]string
+
+	// SampleRate is the fraction of traces recorded, between 0 and 1. Zero
+	// records every trace. Spans of an unsampled trace are not sent and
+	// logs written under it carry no trace IDs.
+	SampleRate float64
+
+	// BatchSize enables background export of logs and metrics: they are
+	// queued and a flush starts once BatchSize are waiting, or every
+	// FlushInterval (default 1s). It only triggers flushes; it doesn't size
+	// requests. The Go-Insight API takes one entry per request, so a flush
+	// sends each queued entry as its own request, in turn. When QueueSize
+	// (default 1000) entries are waiting, new ones are dropped with
+	// ErrQueueFull. Zero sends every log and metric inline.
+	BatchSize     int
+	FlushInterval time.Duration
+	QueueSize     int
+
+	// MaxRetries resends requests that fail with a retryable status or a
+	// network error, waiting RetryBackoff (default 100ms) doubled on each
+	// attempt, or the server's Retry-After when longer
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// RedactKeys lists keys whose values are redacted before they are sent.
+	// A key matches when it contains an entry, ignoring case, "-" and "_".
+	// RedactDetectors names built-in detectors ("email", "jwt",
+	// "card_number") scanning every string value, and RedactStrategy how
+	// sensitive values are rewritten: "replace" (default), "mask" or
+	// "hash". See Redactor.
+	RedactKeys      []string
+	RedactDetectors []string
+	RedactStrategy  string
+
+	// MinLevel drops logs below this level, one of "debug" (default),
+	// "info", "warn" or "error". LoggerLevels overrides it for named
+	// loggers and the loggers below them. Both can be changed at runtime.
+	MinLevel     string
+	LoggerLevels map[string]string
+
+	// ErrorWindow is how long repeats of an error passed to CaptureError
+	// are counted before their count is sent, 1 minute by default
+	ErrorWindow time.Duration
+
+	// LogRateLimit caps the logs sent per second for each level and message
+	// template, allowing bursts of LogRateBurst (default: the rate, at
+	// least 1). Numbers, hex IDs and UUIDs in messages are ignored when
+	// grouping. What is suppressed is reported every LogSummaryInterval
+	// (default 1 minute) in one log per template. Zero disables the limit.
+	LogRateLimit       float64
+	LogRateBurst       int
+	LogSummaryInterval time.Duration
+
+	// SinkType mirrors telemetry locally: "console" writes readable lines
+	// and "json" JSON lines to stderr, "file" JSON lines to SinkPath,
+	// rotated past SinkMaxSize bytes (default 10 MiB) keeping SinkMaxFiles
+	// (default 5). SinkMode "tee" (default) writes everything, "fallback"
+	// only what fails to export.
+	SinkType     string
+	SinkMode     string
+	SinkPath     string
+	SinkMaxSize  int64
+	SinkMaxFiles int
+
+	// BreakerErrorRate opens a circuit breaker around Go-Insight when at
+	// least this fraction of the calls in a BreakerWindow (default 10s)
+	// fail, once BreakerMinRequests (default 20) calls were made. Calls
+	// taking BreakerSlowCall or longer count as failures. While open,
+	// telemetry is dropped with ErrCircuitOpen, or written to fallback
+	// sinks, and traces and spans are started as local no-ops; after
+	// BreakerOpenTimeout (default 30s) one call probes whether Go-Insight
+	// has recovered. The breaker is disabled unless BreakerErrorRate or
+	// BreakerSlowCall is set; with only the latter, the rate is 0.5.
+	BreakerErrorRate   float64
+	BreakerMinRequests int
+	BreakerWindow      time.Duration
+	BreakerSlowCall    time.Duration
+	BreakerOpenTimeout time.Duration
+
+	// StatsInterval sends the client's Stats to Go-Insight as a metric
+	// with Path StatsMetricPath this often. Zero disables the reports.
+	StatsInterval time.Duration
 }
 
 // LogEntry represents a log entry to be sent to Go-Insight
@@ -15,9 +110,11 @@ type LogEntry struct {
 	ServiceName string                 `json:"service_name"`
 	LogLevel    string                 `json:"log_level"`
 	Message     string                 `json:"message"`
+	Timestamp   time.Time              `json:"timestamp"`
 	TraceID     string                 `json:"trace_id,omitempty"`
 	SpanID      string                 `json:"span_id,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Resource    Resource               `json:"resource,omitempty"`
 }
 
 // Metric represents a performance metric
@@ -31,6 +128,7 @@ type Metric struct {
 	Environment string                 `json:"environment,omitempty"`
 	RequestID   string                 `json:"request_id,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Resource    Resource               `json:"resource,omitempty"`
 }
 
 // MetricSource represents the source of a metric
@@ -42,21 +140,109 @@ type MetricSource struct {
 
 // Trace represents a distributed trace
 type Trace struct {
-	ID          string `json:"id,omitempty"`
-	ServiceName string `json:"service_name"`
+	ID          string   `json:"id,omitempty"`
+	ServiceName string   `json:"service_name"`
+	Resource    Resource `json:"resource,omitempty"`
 }
 
 // Span represents a span within a trace
 type Span struct {
-	ID        string `json:"id,omitempty"`
-	TraceID   string `json:"trace_id"`
-	ParentID  string `json:"parent_id,omitempty"`
-	Service   string `json:"service"`
-	Operation string `json:"operation"`
+	ID         string                 `json:"id,omitempty"`
+	TraceID    string                 `json:"trace_id"`
+	ParentID   string                 `json:"parent_id,omitempty"`
+	Service    string                 `json:"service"`
+	Operation  string                 `json:"operation"`
+	StartTime  time.Time              `json:"start_time"`
+	Kind       SpanKind               `json:"kind,omitempty"`
+	Links      []SpanLink             `json:"links,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// SpanKind describes a span's role in the interaction it records
+type SpanKind string
+
+// Span kinds. Spans without a kind are treated as internal.
+const (
+	SpanKindInternal SpanKind = "internal"
+	SpanKindServer   SpanKind = "server"
+	SpanKindClient   SpanKind = "client"
+	SpanKindProducer SpanKind = "producer"
+	SpanKindConsumer SpanKind = "consumer"
+)
+
+// SpanLink relates a span to a span other than its parent, possibly in
+// another trace, such as each message a batch consumer processes
+type SpanLink struct {
+	TraceID    string                 `json:"trace_id"`
+	SpanID     string                 `json:"span_id,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Span statuses reported when a span finishes
+const (
+	SpanStatusOK    = "ok"
+	SpanStatusError = "error"
+)
+
+// SpanEnd carries the outcome of a span when it finishes
+type SpanEnd struct {
+	EndTime    time.Time              `json:"end_time"`
+	Duration   float64                `json:"duration_ms"`
+	Status     string                 `json:"status,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // TraceContext holds trace information in context
 type TraceContext struct {
 	TraceID string
 	SpanID  string
+
+	// start is when the current span started, with a monotonic reading
+	// unless it was backdated. It is zero for remote spans.
+	start time.Time
+
+	// unsampled marks a trace dropped by sampling. It has no IDs and none
+	// of its spans are sent.
+	unsampled bool
+}
+
+// createResponse is the body Go-Insight returns when a trace or span is created
+type createResponse struct {
+	ID    resourceID `json:"id"`
+	Error string     `json:"error,omitempty"`
+}
+
+// validate rejects responses that carry an error message or no ID
+func (r createResponse) validate(method, path string) error {
+	if r.Error != "" {
+		return &ResponseError{Method: method, Path: path, Reason: r.Error}
+	}
+	if r.ID == "" {
+		return &ResponseError{Method: method, Path: path, Reason: "missing id"}
+	}
+	return nil
+}
+
+// resourceID accepts IDs encoded either as JSON strings or as JSON numbers
+type resourceID string
+
+func (id *resourceID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = resourceID(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("id must be a string or number, got %s", data)
+	}
+	*id = resourceID(n.String())
+	return nil
 }
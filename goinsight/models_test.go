@@ -0,0 +1,52 @@
+package goinsight
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestStartTraceResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+		reason string
+	}{
+		{"string id", http.StatusCreated, `{"id":"abc"}`, "abc", ""},
+		{"numeric id", http.StatusCreated, `{"id":42}`, "42", ""},
+		{"error body with 2xx", http.StatusOK, `{"id":"abc","error":"quota exceeded"}`, "", "quota exceeded"},
+		{"missing id", http.StatusOK, `{}`, "", "missing id"},
+		{"non-JSON body", http.StatusOK, `<html>maintenance</html>`, "", "failed to decode response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newHTTPClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+
+			_, traceCtx, err := client.StartTrace(context.Background(), "checkout")
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if traceCtx.TraceID != tt.want {
+					t.Errorf("TraceID = %q, want %q", traceCtx.TraceID, tt.want)
+				}
+				return
+			}
+
+			var respErr *ResponseError
+			if !errors.As(err, &respErr) {
+				t.Fatalf("err = %v, want a *ResponseError", err)
+			}
+			if respErr.Path != "/traces" || respErr.Reason != tt.reason {
+				t.Errorf("ResponseError = %+v, want path /traces and reason %q", *respErr, tt.reason)
+			}
+		})
+	}
+}
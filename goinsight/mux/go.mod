@@ -0,0 +1,8 @@
+module github.com/NathanSanchezDev/go-insight-go-sdk/goinsight/mux
+
+go 1.21
+
+require (
+	github.com/NathanSanchezDev/go-insight-go-sdk v0.2.0
+	github.com/gorilla/mux v1.8.1
+)
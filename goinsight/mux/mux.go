@@ -0,0 +1,105 @@
+// Package goinsightmux provides Go-Insight middleware for gorilla/mux routers
+package goinsightmux
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/gorilla/mux"
+)
+
+// gorilla/mux exposes no version constant, so its version comes from the
+// build info
+const muxModule = "github.com/gorilla/mux"
+
+// Middleware returns a gorilla/mux middleware for automatic instrumentation.
+// Register it with Router.Use; mux only runs it for matched routes.
+func Middleware(client *goinsight.Client, opts ...goinsight.MiddlewareOption) mux.MiddlewareFunc {
+	inst := client.NewServerInstrumentation(goinsight.MetricSource{
+		Language:  "go",
+		Framework: "gorilla/mux",
+		Version:   goinsight.ModuleVersion(muxModule),
+	}, opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if inst.Skip(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			route := routeTemplate(r)
+
+			// Start trace for this request
+			ctx, traceCtx := inst.Start(r.Context(), r.Method, route)
+			r = r.WithContext(ctx)
+
+			// Process request
+			rw := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			inst.Finish(r.Context(), traceCtx, goinsight.CompletedRequest{
+				Method:         r.Method,
+				Route:          route,
+				StatusCode:     rw.status(),
+				Duration:       time.Since(start),
+				RequestHeader:  r.Header,
+				ResponseHeader: rw.Header(),
+			})
+		})
+	}
+}
+
+// routeTemplate returns the path template of the matched route
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return tpl
+}
+
+// statusRecorder captures the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (rw *statusRecorder) WriteHeader(statusCode int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = statusCode
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *statusRecorder) Write(b []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Flush forwards to the underlying writer when it supports streaming
+func (rw *statusRecorder) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rw *statusRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *statusRecorder) status() int {
+	if rw.statusCode == 0 {
+		return http.StatusOK
+	}
+	return rw.statusCode
+}
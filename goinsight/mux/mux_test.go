@@ -0,0 +1,148 @@
+package goinsightmux
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/gorilla/mux"
+)
+
+// backend is a fake Go-Insight API recording the metrics it receives
+type backend struct {
+	ids atomic.Int64
+
+	mu      sync.Mutex
+	metrics []goinsight.Metric
+}
+
+func (b *backend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/metrics":
+		var m goinsight.Metric
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b.mu.Lock()
+		b.metrics = append(b.metrics, m)
+		b.mu.Unlock()
+	case r.URL.Path == "/traces" || r.URL.Path == "/spans":
+		fmt.Fprintf(w, `{"id":"%d"}`, b.ids.Add(1))
+		return
+	case strings.HasSuffix(r.URL.Path, "/end"), r.URL.Path == "/logs":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (b *backend) received() []goinsight.Metric {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]goinsight.Metric(nil), b.metrics...)
+}
+
+// TestMiddlewareConcurrentRequests checks that every metric reports the
+// path template of its own route, including routes on subrouters. Run it
+// with -race.
+func TestMiddlewareConcurrentRequests(t *testing.T) {
+	api := &backend{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	client, err := goinsight.NewClient(
+		goinsight.WithEndpoint(srv.URL),
+		goinsight.WithAPIKey("test"),
+		goinsight.WithServiceName("mux-test"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	routes := []struct {
+		path   string
+		route  string
+		status int
+	}{
+		{"/ok/%d", "/ok/{id}", http.StatusOK},
+		{"/created/%d", "/created/{id}", http.StatusCreated},
+		{"/api/orders/%d", "/api/orders/{id}", http.StatusNotFound},
+		{"/api/orders/%d/fail", "/api/orders/{id}/fail", http.StatusInternalServerError},
+	}
+
+	router := mux.NewRouter()
+	router.Use(Middleware(client))
+	orders := router.PathPrefix("/api").Subrouter()
+	for _, r := range routes {
+		status := r.status
+		handler := func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(status)
+		}
+		if sub, ok := strings.CutPrefix(r.route, "/api"); ok {
+			orders.HandleFunc(sub, handler).Methods(http.MethodGet)
+		} else {
+			router.HandleFunc(r.route, handler).Methods(http.MethodGet)
+		}
+	}
+	app := httptest.NewServer(router)
+	defer app.Close()
+
+	const requests = 200
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			r := routes[i%len(routes)]
+			req, _ := http.NewRequest(http.MethodGet, app.URL+fmt.Sprintf(r.path, i), nil)
+			req.Header.Set("X-Request-ID", fmt.Sprint(i))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}(i)
+	}
+	wg.Wait()
+
+	// Metrics are exported asynchronously
+	deadline := time.Now().Add(10 * time.Second)
+	for len(api.received()) < requests && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	metrics := api.received()
+	if len(metrics) != requests {
+		t.Fatalf("received %d metrics, want %d", len(metrics), requests)
+	}
+	seen := make(map[string]bool, requests)
+	for _, m := range metrics {
+		var i int
+		if _, err := fmt.Sscan(m.RequestID, &i); err != nil {
+			t.Fatalf("metric without request ID: %+v", m)
+		}
+		want := routes[i%len(routes)]
+		if m.Path != want.route || m.StatusCode != want.status || m.Method != http.MethodGet {
+			t.Errorf("request %d: got %s %s %d, want GET %s %d", i, m.Method, m.Path, m.StatusCode, want.route, want.status)
+		}
+		if seen[m.RequestID] {
+			t.Errorf("request %d reported twice", i)
+		}
+		seen[m.RequestID] = true
+		if m.Source.Framework != "gorilla/mux" {
+			t.Errorf("request %d: framework %q, want gorilla/mux", i, m.Source.Framework)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package goinsightnats propagates Go-Insight traces through nats.go message
+// headers
+package goinsightnats
+
+import (
+	"context"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/nats-io/nats.go"
+)
+
+// nats.Header already has the Get and Set methods of a carrier
+var _ goinsight.Carrier = nats.Header{}
+
+// Publish publishes msg on nc with the trace context of ctx in its headers,
+// recording a "nats.send" span when ctx carries a trace. msg gets a copy of
+// its headers, so a Header shared between messages isn't modified. Headers
+// require a server with header support.
+func Publish(ctx context.Context, client *goinsight.Client, nc *nats.Conn, msg *nats.Msg) error {
+	spanCtx, finish := client.StartProducerSpan(ctx, "nats.send", map[string]interface{}{
+		"messaging.system":      "nats",
+		"messaging.operation":   "send",
+		"messaging.destination": msg.Subject,
+	})
+
+	header := make(nats.Header, len(msg.Header)+2)
+	for k, v := range msg.Header {
+		header[k] = v
+	}
+	goinsight.Inject(spanCtx, header)
+	msg.Header = header
+
+	err := nc.PublishMsg(msg)
+	finish(err)
+	return err
+}
+
+// Process runs fn for msg inside a "nats.process" span for its subject. A
+// message published without headers, or by a service that isn't traced,
+// starts a new trace.
+func Process(ctx context.Context, client *goinsight.Client, msg *nats.Msg, fn func(ctx context.Context) error) error {
+	carrier := msg.Header
+	if carrier == nil {
+		carrier = nats.Header{}
+	}
+
+	return client.ProcessMessage(ctx, carrier, "nats.process", map[string]interface{}{
+		"messaging.system":      "nats",
+		"messaging.operation":   "process",
+		"messaging.destination": msg.Subject,
+	}, fn)
+}
+
+// Handler adapts fn to a nats.MsgHandler that processes every message with
+// Process. Errors returned by fn are recorded on the span.
+//
+//	nc.Subscribe("orders.created", goinsightnats.Handler(client, handleOrder))
+func Handler(client *goinsight.Client, fn func(ctx context.Context, msg *nats.Msg) error) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		Process(context.Background(), client, msg, func(ctx context.Context) error {
+			return fn(ctx, msg)
+		})
+	}
+}
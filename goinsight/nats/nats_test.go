@@ -0,0 +1,28 @@
+package goinsightnats
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/nats-io/nats.go"
+)
+
+func TestHeaderCarrierRoundTrip(t *testing.T) {
+	header := nats.Header{}
+	header.Add(goinsight.TraceIDHeader, "stale")
+	header.Add(goinsight.TraceIDHeader, "duplicate")
+
+	var carrier goinsight.Carrier = header
+	carrier.Set(goinsight.TraceIDHeader, "trace-1")
+	carrier.Set(goinsight.SpanIDHeader, "span-2")
+
+	if values := header.Values(goinsight.TraceIDHeader); len(values) != 1 || values[0] != "trace-1" {
+		t.Errorf("Set left %v", values)
+	}
+
+	traceCtx := goinsight.GetTraceFromContext(goinsight.Extract(context.Background(), carrier))
+	if traceCtx == nil || traceCtx.TraceID != "trace-1" || traceCtx.SpanID != "span-2" {
+		t.Errorf("Extract = %+v", traceCtx)
+	}
+}
@@ -0,0 +1,233 @@
+package goinsight
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a client created with NewClient
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	config     Config
+	httpClient *http.Client
+	exporter   Exporter
+	sampler    Sampler
+	resource   Resource
+	redactor   *Redactor
+	minLevel   *Level
+
+	sinks         []Sink
+	fallbackSinks []Sink
+
+	logProcessors    []LogProcessor
+	spanProcessors   []SpanProcessor
+	metricProcessors []MetricProcessor
+}
+
+// WithConfig starts from config; later options override its fields
+func WithConfig(config Config) Option {
+	return func(o *clientOptions) {
+		o.config = config
+	}
+}
+
+// WithEndpoint sets the Go-Insight server URL
+func WithEndpoint(endpoint string) Option {
+	return func(o *clientOptions) {
+		o.config.Endpoint = endpoint
+	}
+}
+
+// WithAPIKey sets the key sent with every request
+func WithAPIKey(apiKey string) Option {
+	return func(o *clientOptions) {
+		o.config.APIKey = apiKey
+	}
+}
+
+// WithServiceName sets the service name recorded on logs, metrics and traces
+func WithServiceName(name string) Option {
+	return func(o *clientOptions) {
+		o.config.ServiceName = name
+	}
+}
+
+// WithTimeout sets the per-request timeout, 5s by default
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.config.Timeout = timeout
+	}
+}
+
+// WithHTTPClient sends requests with client, for proxies, custom TLS or
+// instrumented transports. Its Timeout, if any, applies on top of the
+// client's. It is ignored when WithExporter is used.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = client
+	}
+}
+
+// WithExporter replaces the HTTP exporter. Endpoint and API key are then
+// not required.
+func WithExporter(exporter Exporter) Option {
+	return func(o *clientOptions) {
+		o.exporter = exporter
+	}
+}
+
+// WithSampler decides which traces are recorded, overriding
+// Config.SampleRate
+func WithSampler(sampler Sampler) Option {
+	return func(o *clientOptions) {
+		o.sampler = sampler
+	}
+}
+
+// WithResource merges res into the detected resource, overriding detected
+// values
+func WithResource(res Resource) Option {
+	return func(o *clientOptions) {
+		if o.resource == nil {
+			o.resource = make(Resource, len(res))
+		}
+		for k, v := range res {
+			o.resource[k] = v
+		}
+	}
+}
+
+// WithBatching queues logs and metrics for background export, flushing once
+// size are waiting or every flushInterval. size only triggers the flush;
+// each entry is still sent as its own request.
+func WithBatching(size int, flushInterval time.Duration) Option {
+	return func(o *clientOptions) {
+		o.config.BatchSize = size
+		o.config.FlushInterval = flushInterval
+	}
+}
+
+// WithRetry resends retryable failures up to maxRetries times, waiting
+// backoff doubled on each attempt
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(o *clientOptions) {
+		o.config.MaxRetries = maxRetries
+		o.config.RetryBackoff = backoff
+	}
+}
+
+// WithRedactKeys redacts the values of keys in metadata, attributes and
+// captured headers
+func WithRedactKeys(keys ...string) Option {
+	return func(o *clientOptions) {
+		n := len(o.config.RedactKeys)
+		o.config.RedactKeys = append(o.config.RedactKeys[:n:n], keys...)
+	}
+}
+
+// WithRedactor scrubs everything the client exports with r, replacing the
+// redaction settings of the config. Use DefaultRedactor for common PII.
+func WithRedactor(r *Redactor) Option {
+	return func(o *clientOptions) {
+		o.redactor = r
+	}
+}
+
+// WithLogProcessors appends processors run on every log entry, in order
+func WithLogProcessors(processors ...LogProcessor) Option {
+	return func(o *clientOptions) {
+		o.logProcessors = append(o.logProcessors, processors...)
+	}
+}
+
+// WithSpanProcessors appends processors run on every span as it starts, in
+// order
+func WithSpanProcessors(processors ...SpanProcessor) Option {
+	return func(o *clientOptions) {
+		o.spanProcessors = append(o.spanProcessors, processors...)
+	}
+}
+
+// WithMetricProcessors appends processors run on every metric, in order
+func WithMetricProcessors(processors ...MetricProcessor) Option {
+	return func(o *clientOptions) {
+		o.metricProcessors = append(o.metricProcessors, processors...)
+	}
+}
+
+// WithMinLevel drops logs below level, overriding Config.MinLevel. The level
+// can be changed later through Client.Level.
+func WithMinLevel(level Level) Option {
+	return func(o *clientOptions) {
+		o.minLevel = &level
+	}
+}
+
+// WithErrorWindow sets how long repeats of a captured error are counted
+// before their count is sent
+func WithErrorWindow(window time.Duration) Option {
+	return func(o *clientOptions) {
+		o.config.ErrorWindow = window
+	}
+}
+
+// WithLogRateLimit sends at most rate logs per second for each level and
+// message template, allowing bursts of burst, and reports what it
+// suppressed once a minute
+func WithLogRateLimit(rate float64, burst int) Option {
+	return func(o *clientOptions) {
+		o.config.LogRateLimit = rate
+		o.config.LogRateBurst = burst
+	}
+}
+
+// WithCircuitBreaker stops sending to Go-Insight for a while when at least
+// errorRate of recent calls fail or take slowCall or longer. A zero
+// errorRate uses 0.5 and a zero slowCall ignores latency. See
+// Config.BreakerErrorRate for the other settings.
+func WithCircuitBreaker(errorRate float64, slowCall time.Duration) Option {
+	return func(o *clientOptions) {
+		o.config.BreakerErrorRate = errorRate
+		o.config.BreakerSlowCall = slowCall
+	}
+}
+
+// WithStatsInterval sends the client's stats to Go-Insight as a metric
+// every interval
+func WithStatsInterval(interval time.Duration) Option {
+	return func(o *clientOptions) {
+		o.config.StatsInterval = interval
+	}
+}
+
+// WithSink writes everything the client exports to sink as well
+func WithSink(sink Sink) Option {
+	return func(o *clientOptions) {
+		o.sinks = append(o.sinks, sink)
+	}
+}
+
+// WithFallbackSink writes what fails to export to sink, such as while
+// Go-Insight is unreachable
+func WithFallbackSink(sink Sink) Option {
+	return func(o *clientOptions) {
+		o.fallbackSinks = append(o.fallbackSinks, sink)
+	}
+}
+
+// openConfigSink adds the sink described by the config to the options.
+// The config's sink is cleared so it is opened only once.
+func (o *clientOptions) openConfigSink() error {
+	sink, err := sinkFromConfig(o.config)
+	o.config.SinkType = ""
+	if err != nil || sink == nil {
+		return err
+	}
+	if o.config.SinkMode == SinkModeFallback {
+		o.fallbackSinks = append(o.fallbackSinks, sink)
+	} else {
+		o.sinks = append(o.sinks, sink)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package goinsightpgx traces pgx v5 queries, batches and COPY operations as
+// Go-Insight spans
+package goinsightpgx
+
+import (
+	"context"
+	"strings"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxBatchStatements caps how many statements of a batch are recorded
+const maxBatchStatements = 20
+
+// Option configures a Tracer
+type Option func(*Tracer)
+
+// WithLiteralPlaceholders replaces string and numeric literals in recorded
+// statements with "?"
+func WithLiteralPlaceholders() Option {
+	return func(t *Tracer) {
+		t.placeholders = true
+	}
+}
+
+// Tracer implements pgx.QueryTracer, pgx.BatchTracer and pgx.CopyFromTracer.
+// Each traced call becomes a child span of the Go-Insight trace in its
+// context; calls without a trace are left alone.
+//
+//	config.ConnConfig.Tracer = goinsightpgx.NewTracer(client)
+type Tracer struct {
+	client       *goinsight.Client
+	placeholders bool
+}
+
+var (
+	_ pgx.QueryTracer    = (*Tracer)(nil)
+	_ pgx.BatchTracer    = (*Tracer)(nil)
+	_ pgx.CopyFromTracer = (*Tracer)(nil)
+)
+
+// NewTracer returns a Tracer reporting to client
+func NewTracer(client *goinsight.Client, opts ...Option) *Tracer {
+	t := &Tracer{client: client}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+type spanKey struct{}
+
+// activeSpan is stored in the context pgx hands back to the End callbacks,
+// marking that this tracer started the span in that context
+type activeSpan struct {
+	statements []string
+	queries    int
+	err        error
+}
+
+// TraceQueryStart starts a span for Query, QueryRow and Exec calls
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return t.start(ctx, "Query", map[string]interface{}{
+		"db.statement": goinsight.SanitizeStatement(data.SQL, t.placeholders),
+	})
+}
+
+// TraceQueryEnd finishes the span started by TraceQueryStart
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.finish(ctx, data.Err, map[string]interface{}{
+		"db.command":       commandName(data.CommandTag.String()),
+		"db.rows_affected": data.CommandTag.RowsAffected(),
+	})
+}
+
+// TraceBatchStart starts a span for SendBatch calls
+func (t *Tracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	size := 0
+	if data.Batch != nil {
+		size = data.Batch.Len()
+	}
+	return t.start(ctx, "Batch", map[string]interface{}{
+		"db.batch_size": size,
+	})
+}
+
+// TraceBatchQuery records each statement of the batch on its span
+func (t *Tracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	span, ok := ctx.Value(spanKey{}).(*activeSpan)
+	if !ok {
+		return
+	}
+
+	span.queries++
+	if len(span.statements) < maxBatchStatements {
+		span.statements = append(span.statements, goinsight.SanitizeStatement(data.SQL, t.placeholders))
+	}
+	if data.Err != nil && span.err == nil {
+		span.err = data.Err
+	}
+}
+
+// TraceBatchEnd finishes the span started by TraceBatchStart
+func (t *Tracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	span, ok := ctx.Value(spanKey{}).(*activeSpan)
+	if !ok {
+		return
+	}
+
+	err := data.Err
+	if err == nil {
+		err = span.err
+	}
+	t.finish(ctx, err, map[string]interface{}{
+		"db.batch_queries":    span.queries,
+		"db.batch_statements": span.statements,
+	})
+}
+
+// TraceCopyFromStart starts a span for CopyFrom calls
+func (t *Tracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	return t.start(ctx, "CopyFrom", map[string]interface{}{
+		"db.table":   data.TableName.Sanitize(),
+		"db.columns": data.ColumnNames,
+	})
+}
+
+// TraceCopyFromEnd finishes the span started by TraceCopyFromStart
+func (t *Tracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	t.finish(ctx, data.Err, map[string]interface{}{
+		"db.rows_affected": data.CommandTag.RowsAffected(),
+	})
+}
+
+func (t *Tracer) start(ctx context.Context, operation string, attrs map[string]interface{}) context.Context {
+	if goinsight.GetTraceFromContext(ctx) == nil {
+		return ctx
+	}
+
+	attrs["db.system"] = "postgresql"
+	attrs["db.operation"] = operation
+
+	spanCtx, err := t.client.StartSpan(ctx, "db."+operation, goinsight.WithSpanKind(goinsight.SpanKindClient), goinsight.WithAttributes(attrs))
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(spanCtx, spanKey{}, &activeSpan{})
+}
+
+func (t *Tracer) finish(ctx context.Context, err error, attrs map[string]interface{}) {
+	if _, ok := ctx.Value(spanKey{}).(*activeSpan); !ok {
+		return
+	}
+
+	// The query context may already be cancelled; the span still has to end
+	t.client.FinishSpan(context.WithoutCancel(ctx), goinsight.WithSpanError(err), goinsight.WithAttributes(attrs))
+}
+
+// commandName returns the command of a tag such as "INSERT 0 1"
+func commandName(tag string) string {
+	name, _, _ := strings.Cut(tag, " ")
+	return name
+}
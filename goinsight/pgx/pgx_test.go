@@ -0,0 +1,187 @@
+package goinsightpgx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// recorder is an exporter keeping the spans it is given, in order
+type recorder struct {
+	mu    sync.Mutex
+	spans []goinsight.Span
+	ends  map[string]goinsight.SpanEnd
+}
+
+func (r *recorder) ExportLog(context.Context, goinsight.LogEntry) error     { return nil }
+func (r *recorder) ExportMetric(context.Context, goinsight.Metric) error    { return nil }
+func (r *recorder) EndTrace(context.Context, string) error                  { return nil }
+func (r *recorder) ExportError(context.Context, goinsight.ErrorEvent) error { return nil }
+func (r *recorder) Shutdown(context.Context) error                          { return nil }
+
+func (r *recorder) StartTrace(context.Context, goinsight.Trace) (string, error) {
+	return "trace-1", nil
+}
+
+func (r *recorder) StartSpan(_ context.Context, span goinsight.Span) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+	return fmt.Sprintf("span-%d", len(r.spans)), nil
+}
+
+func (r *recorder) EndSpan(_ context.Context, spanID string, end goinsight.SpanEnd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ends == nil {
+		r.ends = make(map[string]goinsight.SpanEnd)
+	}
+	r.ends[spanID] = end
+	return nil
+}
+
+// startTrace returns a client exporting to a new recorder and a context
+// carrying one of its traces. The trace's root span is span-1.
+func startTrace(t *testing.T) (context.Context, *goinsight.Client, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	client, err := goinsight.NewClient(goinsight.WithServiceName("pgx-test"), goinsight.WithExporter(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	ctx, _, err := client.StartTrace(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx, client, rec
+}
+
+func TestTraceQuery(t *testing.T) {
+	ctx, client, rec := startTrace(t)
+	tracer := NewTracer(client, WithLiteralPlaceholders())
+
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{
+		SQL: "INSERT INTO users (email) VALUES ('a@b.c')",
+	})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{
+		CommandTag: pgconn.NewCommandTag("INSERT 0 1"),
+	})
+
+	if len(rec.spans) != 2 {
+		t.Fatalf("got %d spans, want the root and one query", len(rec.spans))
+	}
+	span := rec.spans[1]
+	if span.Operation != "db.Query" || span.Kind != goinsight.SpanKindClient || span.ParentID != "span-1" {
+		t.Errorf("span = %+v", span)
+	}
+	if got := span.Attributes["db.statement"]; got != "INSERT INTO users (email) VALUES (?)" {
+		t.Errorf("db.statement = %v", got)
+	}
+	if span.Attributes["db.system"] != "postgresql" {
+		t.Errorf("db.system = %v", span.Attributes["db.system"])
+	}
+
+	end := rec.ends["span-2"]
+	if end.Status != goinsight.SpanStatusOK {
+		t.Errorf("status %q, want ok", end.Status)
+	}
+	if end.Attributes["db.command"] != "INSERT" || end.Attributes["db.rows_affected"] != int64(1) {
+		t.Errorf("end attributes = %v", end.Attributes)
+	}
+}
+
+func TestTraceQueryError(t *testing.T) {
+	ctx, client, rec := startTrace(t)
+	tracer := NewTracer(client)
+
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{Err: errors.New("relation does not exist")})
+
+	if end := rec.ends["span-2"]; end.Status != goinsight.SpanStatusError || end.Error != "relation does not exist" {
+		t.Errorf("end = %+v", end)
+	}
+}
+
+func TestTraceBatch(t *testing.T) {
+	ctx, client, rec := startTrace(t)
+	tracer := NewTracer(client)
+
+	const queries = maxBatchStatements + 5
+	batch := &pgx.Batch{}
+	for i := 0; i < queries; i++ {
+		batch.Queue(fmt.Sprintf("UPDATE jobs SET done = true WHERE id = %d", i))
+	}
+
+	errConflict := errors.New("serialization failure")
+	batchCtx := tracer.TraceBatchStart(ctx, nil, pgx.TraceBatchStartData{Batch: batch})
+	for i, query := range batch.QueuedQueries {
+		data := pgx.TraceBatchQueryData{SQL: query.SQL}
+		if i == 3 {
+			data.Err = errConflict
+		}
+		tracer.TraceBatchQuery(batchCtx, nil, data)
+	}
+	tracer.TraceBatchEnd(batchCtx, nil, pgx.TraceBatchEndData{})
+
+	if len(rec.spans) != 2 {
+		t.Fatalf("got %d spans, want the root and one batch", len(rec.spans))
+	}
+	span := rec.spans[1]
+	if span.Operation != "db.Batch" || span.Attributes["db.batch_size"] != queries {
+		t.Errorf("span = %s, batch_size %v", span.Operation, span.Attributes["db.batch_size"])
+	}
+
+	end := rec.ends["span-2"]
+	if end.Attributes["db.batch_queries"] != queries {
+		t.Errorf("batch_queries = %v, want %d", end.Attributes["db.batch_queries"], queries)
+	}
+	if statements, _ := end.Attributes["db.batch_statements"].([]string); len(statements) != maxBatchStatements {
+		t.Errorf("recorded %d statements, want %d", len(statements), maxBatchStatements)
+	}
+	// A failed statement fails the batch even when SendBatch's Close succeeds
+	if end.Status != goinsight.SpanStatusError || end.Error != errConflict.Error() {
+		t.Errorf("status %q, error %q", end.Status, end.Error)
+	}
+}
+
+func TestTraceCopyFrom(t *testing.T) {
+	ctx, client, rec := startTrace(t)
+	tracer := NewTracer(client)
+
+	copyCtx := tracer.TraceCopyFromStart(ctx, nil, pgx.TraceCopyFromStartData{
+		TableName:   pgx.Identifier{"public", "events"},
+		ColumnNames: []string{"id", "name"},
+	})
+	tracer.TraceCopyFromEnd(copyCtx, nil, pgx.TraceCopyFromEndData{CommandTag: pgconn.NewCommandTag("COPY 250")})
+
+	span := rec.spans[1]
+	if span.Operation != "db.CopyFrom" || span.Attributes["db.table"] != `"public"."events"` {
+		t.Errorf("span = %s, table %v", span.Operation, span.Attributes["db.table"])
+	}
+	if rows := rec.ends["span-2"].Attributes["db.rows_affected"]; rows != int64(250) {
+		t.Errorf("rows_affected = %v, want 250", rows)
+	}
+}
+
+func TestTraceWithoutTrace(t *testing.T) {
+	_, client, rec := startTrace(t)
+	tracer := NewTracer(client)
+
+	ctx := context.Background()
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+	tracer.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "SELECT 1"})
+	tracer.TraceBatchEnd(ctx, nil, pgx.TraceBatchEndData{})
+
+	if queryCtx != ctx || len(rec.spans) != 1 {
+		t.Errorf("got %d spans for calls without a trace", len(rec.spans)-1)
+	}
+}
@@ -0,0 +1,158 @@
+package goinsight
+
+import (
+	"context"
+	"time"
+)
+
+// LogProcessor enriches, rewrites or drops log entries before they are
+// exported. Processors run in the order they were registered, after the
+// built-in trace correlation and before redaction and rate limiting, so
+// entries they drop never count against the limit.
+type LogProcessor interface {
+	// ProcessLog may modify entry; returning false drops it
+	ProcessLog(ctx context.Context, entry *LogEntry) bool
+}
+
+// SpanProcessor enriches, rewrites or drops spans when they start. A span
+// dropped when it starts is not recorded, nor are its descendants. The
+// root span of a new trace has no TraceID yet; the trace is only created
+// when the span is kept.
+type SpanProcessor interface {
+	// ProcessSpan may modify span; returning false drops it
+	ProcessSpan(ctx context.Context, span *Span) bool
+}
+
+// MetricProcessor enriches, rewrites or drops metrics before they are
+// exported
+type MetricProcessor interface {
+	// ProcessMetric may modify metric; returning false drops it
+	ProcessMetric(ctx context.Context, metric *Metric) bool
+}
+
+// LogProcessorFunc adapts a function to LogProcessor
+type LogProcessorFunc func(ctx context.Context, entry *LogEntry) bool
+
+// ProcessLog returns f(ctx, entry)
+func (f LogProcessorFunc) ProcessLog(ctx context.Context, entry *LogEntry) bool {
+	return f(ctx, entry)
+}
+
+// SpanProcessorFunc adapts a function to SpanProcessor
+type SpanProcessorFunc func(ctx context.Context, span *Span) bool
+
+// ProcessSpan returns f(ctx, span)
+func (f SpanProcessorFunc) ProcessSpan(ctx context.Context, span *Span) bool {
+	return f(ctx, span)
+}
+
+// MetricProcessorFunc adapts a function to MetricProcessor
+type MetricProcessorFunc func(ctx context.Context, metric *Metric) bool
+
+// ProcessMetric returns f(ctx, metric)
+func (f MetricProcessorFunc) ProcessMetric(ctx context.Context, metric *Metric) bool {
+	return f(ctx, metric)
+}
+
+// TraceCorrelation returns the processor stamping log entries with the
+// trace and span in ctx. The client always runs it first.
+func TraceCorrelation() LogProcessor {
+	return traceCorrelation
+}
+
+var traceCorrelation = LogProcessorFunc(func(ctx context.Context, entry *LogEntry) bool {
+	if traceCtx := GetTraceFromContext(ctx); traceCtx != nil {
+		entry.TraceID = traceCtx.TraceID
+		entry.SpanID = traceCtx.SpanID
+	}
+	return true
+})
+
+// ProcessLog redacts the entry's message and metadata
+func (r *Redactor) ProcessLog(_ context.Context, entry *LogEntry) bool {
+	entry.Message = r.RedactString(entry.Message)
+	entry.Metadata = r.RedactMap(entry.Metadata)
+	return true
+}
+
+// ProcessSpan redacts the span's attributes and link attributes
+func (r *Redactor) ProcessSpan(_ context.Context, span *Span) bool {
+	span.Attributes = r.RedactMap(span.Attributes)
+	if r != nil && len(span.Links) > 0 {
+		links := make([]SpanLink, len(span.Links))
+		for i, link := range span.Links {
+			link.Attributes = r.RedactMap(link.Attributes)
+			links[i] = link
+		}
+		span.Links = links
+	}
+	return true
+}
+
+// ProcessMetric redacts the metric's metadata
+func (r *Redactor) ProcessMetric(_ context.Context, metric *Metric) bool {
+	metric.Metadata = r.RedactMap(metric.Metadata)
+	return true
+}
+
+// processLog correlates entry with the trace in ctx, runs the log
+// processors and rate limits what they keep, reporting whether entry is
+// kept. A suppressed sample thus carries its trace into the summary, and
+// is already redacted.
+func (c *Client) processLog(ctx context.Context, entry *LogEntry) bool {
+	traceCorrelation.ProcessLog(ctx, entry)
+	if !c.runLogProcessors(ctx, entry) {
+		return false
+	}
+	return c.limiter == nil || c.limiter.allow(entry, time.Now())
+}
+
+// runLogProcessors runs the log processors on a correlated entry, reporting
+// whether it is kept
+func (c *Client) runLogProcessors(ctx context.Context, entry *LogEntry) bool {
+	if len(c.logProcessors) > 0 {
+		// Processors may add keys; keep the caller's map intact
+		entry.Metadata = copyMap(entry.Metadata)
+	}
+	for _, p := range c.logProcessors {
+		if !p.ProcessLog(ctx, entry) {
+			return false
+		}
+	}
+	return c.redact.ProcessLog(ctx, entry)
+}
+
+// processSpan runs the span processors, reporting whether span is kept
+func (c *Client) processSpan(ctx context.Context, span *Span) bool {
+	if len(c.spanProcessors) > 0 {
+		span.Attributes = copyMap(span.Attributes)
+	}
+	for _, p := range c.spanProcessors {
+		if !p.ProcessSpan(ctx, span) {
+			return false
+		}
+	}
+	return c.redact.ProcessSpan(ctx, span)
+}
+
+// processMetric runs the metric processors, reporting whether metric is kept
+func (c *Client) processMetric(ctx context.Context, metric *Metric) bool {
+	if len(c.metricProcessors) > 0 {
+		metric.Metadata = copyMap(metric.Metadata)
+	}
+	for _, p := range c.metricProcessors {
+		if !p.ProcessMetric(ctx, metric) {
+			return false
+		}
+	}
+	return c.redact.ProcessMetric(ctx, metric)
+}
+
+// copyMap returns a shallow copy of m, never nil so processors can add keys
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+package goinsight
+
+import (
+	"context"
+	"net/http"
+)
+
+// Headers that carry trace context between services. SampledHeader is "0"
+// when the sender's trace was dropped by sampling, so the receiver drops its
+// part too instead of starting a new trace; Inject sets it to "1" when
+// reusing a carrier that already holds it.
+const (
+	TraceIDHeader = "X-Trace-ID"
+	SpanIDHeader  = "X-Span-ID"
+	SampledHeader = "X-Trace-Sampled"
+)
+
+// Carrier holds propagated trace context, such as HTTP or message headers
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// HeaderCarrier adapts http.Header to Carrier
+type HeaderCarrier http.Header
+
+// Get returns the first value of key
+func (h HeaderCarrier) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+// Set replaces the values of key
+func (h HeaderCarrier) Set(key, value string) {
+	http.Header(h).Set(key, value)
+}
+
+// MapCarrier adapts a string map to Carrier
+type MapCarrier map[string]string
+
+// Get returns the value of key
+func (m MapCarrier) Get(key string) string {
+	return m[key]
+}
+
+// Set stores value under key
+func (m MapCarrier) Set(key, value string) {
+	m[key] = value
+}
+
+// Inject writes the trace context of ctx into carrier, or only marks it
+// unsampled when the trace was dropped by sampling. It does nothing when ctx
+// carries no trace.
+func Inject(ctx context.Context, carrier Carrier) {
+	traceCtx := GetTraceFromContext(ctx)
+	if traceCtx == nil {
+		return
+	}
+	if traceCtx.unsampled {
+		carrier.Set(SampledHeader, "0")
+		return
+	}
+	if traceCtx.TraceID == "" {
+		return
+	}
+
+	carrier.Set(TraceIDHeader, traceCtx.TraceID)
+	if traceCtx.SpanID != "" {
+		carrier.Set(SpanIDHeader, traceCtx.SpanID)
+	}
+	// Carriers can't delete, so a "0" left by an earlier hop is overwritten
+	if carrier.Get(SampledHeader) != "" {
+		carrier.Set(SampledHeader, "1")
+	}
+}
+
+// Extract returns a copy of ctx carrying the trace context propagated in
+// carrier, so spans started from it become children of the remote span. A
+// trace marked unsampled stays unsampled, and ctx is returned unchanged when
+// carrier holds no trace.
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	if carrier.Get(SampledHeader) == "0" {
+		return context.WithValue(ctx, "go-insight-trace", &TraceContext{unsampled: true})
+	}
+
+	traceID := carrier.Get(TraceIDHeader)
+	if traceID == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, "go-insight-trace", &TraceContext{
+		TraceID: traceID,
+		SpanID:  carrier.Get(SpanIDHeader),
+	})
+}
+
+// ExtractLink returns a link to the span propagated in carrier. ok is false
+// when carrier holds no trace.
+func ExtractLink(carrier Carrier) (link SpanLink, ok bool) {
+	traceID := carrier.Get(TraceIDHeader)
+	if traceID == "" {
+		return SpanLink{}, false
+	}
+	return SpanLink{TraceID: traceID, SpanID: carrier.Get(SpanIDHeader)}, true
+}
+
+// ContinueTrace starts a span for operation under the trace propagated in
+// carrier, or starts a new trace when carrier holds none. It is how message
+// consumers pick up the producer's trace. The returned finish function ends
+// the span, and the trace when one was started; it is a no-op when nothing
+// could be started, in which case ctx is returned unchanged with the error.
+// A continued span is also linked to the remote span. Nothing is recorded
+// for a trace the sender marked unsampled.
+func (c *Client) ContinueTrace(ctx context.Context, carrier Carrier, operation string, opts ...SpanOption) (context.Context, func(opts ...SpanOption), error) {
+	if remoteCtx := Extract(ctx, carrier); remoteCtx != ctx {
+		if link, ok := ExtractLink(carrier); ok {
+			opts = append([]SpanOption{WithLinks(link)}, opts...)
+		}
+		spanCtx, err := c.StartSpan(remoteCtx, operation, opts...)
+		if err != nil {
+			return ctx, func(...SpanOption) {}, err
+		}
+		return spanCtx, func(opts ...SpanOption) {
+			finishCtx, cancel := c.exportContext(spanCtx)
+			defer cancel()
+
+			c.FinishSpan(finishCtx, opts...)
+		}, nil
+	}
+
+	traceCtx, _, err := c.StartTrace(ctx, operation, opts...)
+	if err != nil {
+		return ctx, func(...SpanOption) {}, err
+	}
+	return traceCtx, func(opts ...SpanOption) {
+		finishCtx, cancel := c.exportContext(traceCtx)
+		defer cancel()
+
+		c.FinishSpan(finishCtx, opts...)
+		c.FinishTrace(finishCtx)
+	}, nil
+}
+
+// StartProducerSpan starts a producer span for a message about to be sent,
+// when ctx carries a trace. Inject into the message from the returned
+// context, then call finish with the send's error and any attributes only
+// known once it is sent. Without a span, ctx is returned and finish does
+// nothing. The message queue integrations publish through it.
+func (c *Client) StartProducerSpan(ctx context.Context, operation string, attrs map[string]interface{}) (context.Context, func(err error, opts ...SpanOption)) {
+	spanCtx, err := c.StartSpan(ctx, operation, WithSpanKind(SpanKindProducer), WithAttributes(attrs))
+	if err != nil {
+		return ctx, func(error, ...SpanOption) {}
+	}
+	return spanCtx, func(err error, opts ...SpanOption) {
+		finishCtx, cancel := c.exportContext(spanCtx)
+		defer cancel()
+
+		c.FinishSpan(finishCtx, append(opts, WithSpanError(err))...)
+	}
+}
+
+// ProcessMessage runs fn for a received message inside a consumer span,
+// continuing the trace propagated in carrier like ContinueTrace. fn's error
+// is recorded on the span and returned. fn still runs, with ctx, when the
+// span can't be started. The message queue integrations consume through it.
+func (c *Client) ProcessMessage(ctx context.Context, carrier Carrier, operation string, attrs map[string]interface{}, fn func(ctx context.Context) error) error {
+	spanCtx, finish, err := c.ContinueTrace(ctx, carrier, operation, WithSpanKind(SpanKindConsumer), WithAttributes(attrs))
+	if err != nil {
+		return fn(ctx)
+	}
+
+	fnErr := fn(spanCtx)
+	finish(WithSpanError(fnErr))
+	return fnErr
+}
@@ -0,0 +1,123 @@
+package goinsight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPropagateSampledTrace(t *testing.T) {
+	producer := &recordingExporter{}
+	client, err := NewClient(WithServiceName("producer"), WithExporter(producer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, _, err := client.StartTrace(context.Background(), "request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendCtx, finish := client.StartProducerSpan(ctx, "queue.send", nil)
+	carrier := MapCarrier{}
+	Inject(sendCtx, carrier)
+	finish(nil)
+
+	if carrier[TraceIDHeader] != "trace-1" || carrier[SpanIDHeader] != "span-2" || carrier[SampledHeader] != "" {
+		t.Fatalf("carrier = %v", carrier)
+	}
+
+	consumer := &recordingExporter{}
+	consumerClient, err := NewClient(WithServiceName("consumer"), WithExporter(consumer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumerClient.Close()
+
+	errHandler := errors.New("handler failed")
+	err = consumerClient.ProcessMessage(context.Background(), carrier, "queue.process", nil, func(ctx context.Context) error {
+		return errHandler
+	})
+	if err != errHandler {
+		t.Fatalf("ProcessMessage returned %v", err)
+	}
+
+	if len(consumer.spans) != 1 {
+		t.Fatalf("got %d consumer spans, want 1", len(consumer.spans))
+	}
+	span := consumer.spans[0]
+	if span.TraceID != "trace-1" || span.ParentID != "span-2" || span.Kind != SpanKindConsumer {
+		t.Errorf("consumer span = %+v", span)
+	}
+	if end := consumer.ends["span-1"]; end.Status != SpanStatusError {
+		t.Errorf("consumer span status %q, want error", end.Status)
+	}
+}
+
+func TestPropagateUnsampledTrace(t *testing.T) {
+	producer := &recordingExporter{}
+	client, err := NewClient(WithServiceName("producer"), WithExporter(producer), WithSampler(NeverSample()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, _, err := client.StartTrace(context.Background(), "request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendCtx, finish := client.StartProducerSpan(ctx, "queue.send", nil)
+	carrier := MapCarrier{}
+	Inject(sendCtx, carrier)
+	finish(nil)
+
+	if carrier[SampledHeader] != "0" || carrier[TraceIDHeader] != "" {
+		t.Fatalf("carrier = %v", carrier)
+	}
+
+	consumer := &recordingExporter{}
+	consumerClient, err := NewClient(WithServiceName("consumer"), WithExporter(consumer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumerClient.Close()
+
+	ran := false
+	err = consumerClient.ProcessMessage(context.Background(), carrier, "queue.process", nil, func(ctx context.Context) error {
+		ran = true
+		if _, err := consumerClient.StartSpan(ctx, "child"); err != nil {
+			t.Errorf("StartSpan under an unsampled message: %v", err)
+		}
+		return nil
+	})
+	if err != nil || !ran {
+		t.Fatalf("ProcessMessage returned %v, ran %v", err, ran)
+	}
+	if len(producer.spans)+len(consumer.spans) != 0 {
+		t.Errorf("unsampled trace recorded %d producer and %d consumer spans", len(producer.spans), len(consumer.spans))
+	}
+}
+
+func TestInjectOverwritesStaleUnsampled(t *testing.T) {
+	client, err := NewClient(WithServiceName("producer"), WithExporter(&recordingExporter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, _, err := client.StartTrace(context.Background(), "request")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Headers forwarded from a message whose trace was dropped
+	carrier := MapCarrier{SampledHeader: "0"}
+	Inject(ctx, carrier)
+
+	if carrier[TraceIDHeader] != "trace-1" || carrier[SampledHeader] == "0" {
+		t.Fatalf("carrier = %v", carrier)
+	}
+	if traceCtx := GetTraceFromContext(Extract(context.Background(), carrier)); traceCtx == nil || traceCtx.TraceID != "trace-1" {
+		t.Errorf("Extract = %+v, want trace-1", traceCtx)
+	}
+}
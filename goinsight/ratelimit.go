@@ -0,0 +1,199 @@
+package goinsight
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxLimitBuckets bounds the templates tracked between flushes. Templates
+// beyond it share one overflow bucket per level.
+const maxLimitBuckets = 1000
+
+// otherTemplate is the template of the overflow buckets
+const otherTemplate = "<other>"
+
+// logLimiter rate limits logs per level and message template with a token
+// bucket each, and reports what it suppressed once per interval
+type logLimiter struct {
+	rate     float64
+	burst    float64
+	interval time.Duration
+	report   func(key limitKey, b *bucket)
+
+	mu      sync.Mutex
+	buckets map[limitKey]*bucket
+	start   sync.Once
+	once    sync.Once
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type limitKey struct {
+	level    string
+	template string
+}
+
+type bucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int
+	sample     LogEntry // latest suppressed entry
+}
+
+func newLogLimiter(rate float64, burst int, interval time.Duration, report func(limitKey, *bucket)) *logLimiter {
+	if burst < 1 {
+		burst = max(1, int(rate))
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &logLimiter{
+		rate:     rate,
+		burst:    float64(burst),
+		interval: interval,
+		report:   report,
+		buckets:  make(map[limitKey]*bucket),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// allow takes a token for entry, reporting whether it may be sent
+func (l *logLimiter) allow(entry *LogEntry, now time.Time) bool {
+	l.start.Do(func() { go l.run() })
+
+	key := limitKey{level: entry.LogLevel, template: messageTemplate(entry.Message)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok && len(l.buckets) >= maxLimitBuckets {
+		key.template = otherTemplate
+		b, ok = l.buckets[key]
+	}
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	b.suppressed++
+	b.sample = *entry
+	return false
+}
+
+func (l *logLimiter) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			l.flush(now)
+		case <-l.stop:
+			l.flush(time.Now())
+			return
+		}
+	}
+}
+
+// flush reports suppressed logs and forgets buckets that have refilled
+func (l *logLimiter) flush(now time.Time) {
+	type pending struct {
+		key limitKey
+		b   bucket
+	}
+	var reports []pending
+
+	l.mu.Lock()
+	for key, b := range l.buckets {
+		if b.suppressed > 0 {
+			reports = append(reports, pending{key, *b})
+			b.suppressed = 0
+			b.sample = LogEntry{}
+			continue
+		}
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.mu.Unlock()
+
+	for i := range reports {
+		l.report(reports[i].key, &reports[i].b)
+	}
+}
+
+// shutdown reports what is still suppressed, waiting until ctx is done at
+// most
+func (l *logLimiter) shutdown(ctx context.Context) error {
+	started := true
+	l.start.Do(func() { started = false })
+	if !started {
+		return nil
+	}
+	l.once.Do(func() { close(l.stop) })
+
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// templateParts match the variable parts of a message: UUIDs, long hex IDs
+// and numbers
+var templateParts = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b|\b[0-9a-fA-F]*[0-9][0-9a-fA-F]*\b`)
+
+// messageTemplate replaces the variable parts of message with "<n>", so
+// "user 42 not found" and "user 7 not found" share a limit
+func messageTemplate(message string) string {
+	return templateParts.ReplaceAllString(message, "<n>")
+}
+
+// reportSuppressed sends a summary of the logs a limiter suppressed at the
+// level of the suppressed logs. It skips the limiter and the closed check so
+// Close can report too.
+func (c *Client) reportSuppressed(key limitKey, b *bucket) {
+	entry := LogEntry{
+		ServiceName: c.serviceName,
+		LogLevel:    key.level,
+		Message:     fmt.Sprintf("suppressed %s similar messages", formatCount(b.suppressed)),
+		Timestamp:   time.Now(),
+		TraceID:     b.sample.TraceID,
+		SpanID:      b.sample.SpanID,
+		Metadata: map[string]interface{}{
+			"suppressed":     b.suppressed,
+			"template":       key.template,
+			"sample_message": b.sample.Message,
+		},
+		Resource: c.resource,
+	}
+	ctx := context.Background()
+	if !c.runLogProcessors(ctx, &entry) {
+		return
+	}
+	c.exportLog(ctx, entry)
+}
+
+// formatCount formats n with thousands separators, e.g. "4,213"
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package goinsight
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSuppressedSummaryCarriesTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	client, err := NewClient(
+		WithConfig(Config{ServiceName: "limit-test", LogRateLimit: 0.001, LogRateBurst: 1, LogSummaryInterval: time.Hour}),
+		WithExporter(exporter),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, traceCtx, err := client.StartTrace(context.Background(), "request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		client.LogWarn(ctx, "retrying order 42")
+	}
+	// Close reports what is still suppressed
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if len(exporter.logs) != 2 {
+		t.Fatalf("got %d logs, want the first and a summary: %+v", len(exporter.logs), exporter.logs)
+	}
+	summary := exporter.logs[1]
+	if summary.Metadata["suppressed"] != 2 {
+		t.Errorf("summary reports %v suppressed, want 2", summary.Metadata["suppressed"])
+	}
+	if summary.TraceID != traceCtx.TraceID || summary.SpanID != traceCtx.SpanID || summary.TraceID == "" {
+		t.Errorf("summary trace %q/%q, want %q/%q", summary.TraceID, summary.SpanID, traceCtx.TraceID, traceCtx.SpanID)
+	}
+}
+
+func TestDroppedLogsSkipTheLimiter(t *testing.T) {
+	exporter := &recordingExporter{}
+	dropHealth := LogProcessorFunc(func(_ context.Context, entry *LogEntry) bool {
+		return entry.Metadata["path"] != "/healthz"
+	})
+	client, err := NewClient(
+		WithConfig(Config{ServiceName: "limit-test", LogRateLimit: 0.001, LogRateBurst: 1, LogSummaryInterval: time.Hour}),
+		WithExporter(exporter),
+		WithLogProcessors(dropHealth),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		client.LogInfo(ctx, "request served", map[string]interface{}{"path": "/healthz"})
+	}
+	client.LogInfo(ctx, "request served", map[string]interface{}{"path": "/orders"})
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if len(exporter.logs) != 1 || exporter.logs[0].Metadata["path"] != "/orders" {
+		t.Errorf("got %+v, want the /orders log and no summary", exporter.logs)
+	}
+}
+
+func TestLimiterBucketsAreCapped(t *testing.T) {
+	limiter := newLogLimiter(0.001, 1, time.Hour, func(limitKey, *bucket) {})
+	defer limiter.shutdown(context.Background())
+
+	// Letters only, so every message is its own template
+	word := func(i int) string {
+		b := []byte(strconv.Itoa(i))
+		for j := range b {
+			b[j] += 'g' - '0'
+		}
+		return string(b)
+	}
+
+	now := time.Now()
+	for i := 0; i < maxLimitBuckets+50; i++ {
+		limiter.allow(&LogEntry{LogLevel: "INFO", Message: "event " + word(i)}, now)
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if len(limiter.buckets) != maxLimitBuckets+1 {
+		t.Errorf("%d buckets, want %d and one overflow", len(limiter.buckets), maxLimitBuckets)
+	}
+	other := limiter.buckets[limitKey{level: "INFO", template: otherTemplate}]
+	if other == nil || other.suppressed != 49 {
+		t.Errorf("overflow bucket = %+v, want 49 suppressed", other)
+	}
+}
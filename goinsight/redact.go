@@ -0,0 +1,281 @@
+package goinsight
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of redacted keys
+const Redacted = "[REDACTED]"
+
+// DefaultRedactKeys are the keys DefaultRedactor denies
+var DefaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "api_key", "apikey",
+	"authorization", "cookie", "credit_card", "card_number", "ssn",
+}
+
+// RedactionStrategy rewrites a sensitive string value
+type RedactionStrategy func(value string) string
+
+// RedactReplace replaces the value with "[REDACTED]". It is the default.
+func RedactReplace(string) string {
+	return Redacted
+}
+
+// RedactMask keeps the last four characters of values longer than eight and
+// masks the rest with "*", so card numbers stay recognizable
+func RedactMask(value string) string {
+	runes := []rune(value)
+	keep := 0
+	if len(runes) > 8 {
+		keep = 4
+	}
+	return strings.Repeat("*", len(runes)-keep) + string(runes[len(runes)-keep:])
+}
+
+// RedactHash replaces the value with a truncated SHA-256 digest, so equal
+// values can still be correlated. Low-entropy values such as card numbers
+// can be brute-forced from their hash; prefer RedactReplace for those.
+func RedactHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// Detector finds sensitive data inside string values regardless of key
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+
+	// Valid filters matches of Pattern, e.g. with a checksum. Optional.
+	Valid func(match string) bool
+}
+
+// Built-in detectors
+var (
+	EmailDetector = Detector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+	JWTDetector = Detector{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	}
+	// CardNumberDetector matches 13 to 19 digit card numbers, optionally
+	// grouped with spaces or dashes, that pass the Luhn check
+	CardNumberDetector = Detector{
+		Name:    "card_number",
+		Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Valid:   luhnValid,
+	}
+)
+
+// detectorsByName resolves detector names used in config files
+var detectorsByName = map[string]Detector{
+	EmailDetector.Name:      EmailDetector,
+	JWTDetector.Name:        JWTDetector,
+	CardNumberDetector.Name: CardNumberDetector,
+}
+
+// strategiesByName resolves strategy names used in config files
+var strategiesByName = map[string]RedactionStrategy{
+	"replace": RedactReplace,
+	"mask":    RedactMask,
+	"hash":    RedactHash,
+}
+
+// Redactor scrubs log and metric metadata, log messages, span attributes,
+// span errors and captured headers before they are exported. Values under a
+// deny-listed key are rewritten whole; detectors rewrite only the matching
+// part of any other string. Nested maps and slices are walked; other types
+// are left as they are. A nil Redactor does nothing.
+type Redactor struct {
+	keys      []string
+	detectors []Detector
+	strategy  RedactionStrategy
+}
+
+// RedactorOption configures a Redactor
+type RedactorOption func(*Redactor)
+
+// RedactKeys denies keys containing any of keys, ignoring case, "-" and "_",
+// so "password" also covers "db_password" and "X-Password"
+func RedactKeys(keys ...string) RedactorOption {
+	return func(r *Redactor) {
+		for _, k := range keys {
+			if k = normalizeKey(k); k != "" {
+				r.keys = append(r.keys, k)
+			}
+		}
+	}
+}
+
+// RedactDetectors adds detectors scanning every string value
+func RedactDetectors(detectors ...Detector) RedactorOption {
+	return func(r *Redactor) {
+		r.detectors = append(r.detectors, detectors...)
+	}
+}
+
+// RedactWith sets the strategy applied to sensitive values
+func RedactWith(strategy RedactionStrategy) RedactorOption {
+	return func(r *Redactor) {
+		r.strategy = strategy
+	}
+}
+
+// NewRedactor returns a Redactor with opts applied
+func NewRedactor(opts ...RedactorOption) *Redactor {
+	r := &Redactor{strategy: RedactReplace}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// DefaultRedactor denies DefaultRedactKeys and detects emails, JWTs and card
+// numbers
+func DefaultRedactor() *Redactor {
+	return NewRedactor(
+		RedactKeys(DefaultRedactKeys...),
+		RedactDetectors(EmailDetector, JWTDetector, CardNumberDetector),
+	)
+}
+
+// redactorFromConfig builds the redactor described by config, or nil when
+// it asks for no redaction
+func redactorFromConfig(config Config) (*Redactor, error) {
+	if len(config.RedactKeys) == 0 && len(config.RedactDetectors) == 0 {
+		return nil, nil
+	}
+
+	r := NewRedactor(RedactKeys(config.RedactKeys...))
+	for _, name := range config.RedactDetectors {
+		d, ok := detectorsByName[strings.ToLower(name)]
+		if !ok {
+			return nil, &ConfigError{Field: "RedactDetectors", Reason: fmt.Sprintf("unknown detector %q", name)}
+		}
+		r.detectors = append(r.detectors, d)
+	}
+	if config.RedactStrategy != "" {
+		strategy, ok := strategiesByName[strings.ToLower(config.RedactStrategy)]
+		if !ok {
+			return nil, &ConfigError{Field: "RedactStrategy", Reason: fmt.Sprintf("unknown strategy %q", config.RedactStrategy)}
+		}
+		r.strategy = strategy
+	}
+	return r, nil
+}
+
+// RedactMap returns a copy of m with sensitive values rewritten at any
+// depth. m itself is never modified.
+func (r *Redactor) RedactMap(m map[string]interface{}) map[string]interface{} {
+	if r == nil || m == nil {
+		return m
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if r.deniedKey(k) {
+			out[k] = r.redactWhole(v)
+			continue
+		}
+		out[k] = r.redactValue(v)
+	}
+	return out
+}
+
+// RedactString rewrites the parts of s matched by a detector
+func (r *Redactor) RedactString(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, d := range r.detectors {
+		s = d.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if d.Valid != nil && !d.Valid(match) {
+				return match
+			}
+			return r.strategy(match)
+		})
+	}
+	return s
+}
+
+func (r *Redactor) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return r.RedactString(v)
+	case map[string]interface{}:
+		return r.RedactMap(v)
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for k, s := range v {
+			if r.deniedKey(k) {
+				out[k] = r.strategy(s)
+				continue
+			}
+			out[k] = r.RedactString(s)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = r.redactValue(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = r.RedactString(s)
+		}
+		return out
+	}
+	return v
+}
+
+// redactWhole rewrites a value found under a denied key. Strings go through
+// the strategy; anything else is replaced outright.
+func (r *Redactor) redactWhole(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return r.strategy(s)
+	}
+	return Redacted
+}
+
+func (r *Redactor) deniedKey(key string) bool {
+	key = normalizeKey(key)
+	for _, denied := range r.keys {
+		if strings.Contains(key, denied) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "-", "")
+	return strings.ReplaceAll(key, "_", "")
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,116 @@
+// Package goinsightredis traces go-redis v9 commands and pipelines as
+// Go-Insight spans
+package goinsightredis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxPipelineCommands caps how many command names of a pipeline are recorded
+const maxPipelineCommands = 20
+
+// Hook implements redis.Hook. Each command or pipeline becomes a child span
+// of the Go-Insight trace in its context; calls without a trace are left
+// alone. Only command names are recorded, never arguments.
+//
+//	rdb.AddHook(goinsightredis.NewHook(client))
+type Hook struct {
+	client *goinsight.Client
+}
+
+var _ redis.Hook = (*Hook)(nil)
+
+// NewHook returns a Hook reporting to client
+func NewHook(client *goinsight.Client) *Hook {
+	return &Hook{client: client}
+}
+
+// DialHook passes dials through untraced
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook traces a single command
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if goinsight.GetTraceFromContext(ctx) == nil {
+			return next(ctx, cmd)
+		}
+
+		name := strings.ToUpper(cmd.Name())
+		spanCtx, err := h.client.StartSpan(ctx, "redis."+name, goinsight.WithSpanKind(goinsight.SpanKindClient), goinsight.WithAttributes(map[string]interface{}{
+			"db.system":    "redis",
+			"db.operation": name,
+		}))
+		if err != nil {
+			return next(ctx, cmd)
+		}
+
+		cmdErr := next(spanCtx, cmd)
+		h.finish(spanCtx, cmdErr, nil)
+		return cmdErr
+	}
+}
+
+// ProcessPipelineHook traces a pipeline or transaction as one span
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if goinsight.GetTraceFromContext(ctx) == nil {
+			return next(ctx, cmds)
+		}
+
+		names := make([]string, 0, min(len(cmds), maxPipelineCommands))
+		for _, cmd := range cmds {
+			if len(names) == maxPipelineCommands {
+				break
+			}
+			names = append(names, strings.ToUpper(cmd.Name()))
+		}
+
+		spanCtx, err := h.client.StartSpan(ctx, "redis.pipeline", goinsight.WithSpanKind(goinsight.SpanKindClient), goinsight.WithAttributes(map[string]interface{}{
+			"db.system":              "redis",
+			"db.operation":           "pipeline",
+			"db.redis.pipeline_size": len(cmds),
+			"db.redis.commands":      names,
+		}))
+		if err != nil {
+			return next(ctx, cmds)
+		}
+
+		pipeErr := next(spanCtx, cmds)
+
+		failed := 0
+		for _, cmd := range cmds {
+			if isFailure(cmd.Err()) {
+				failed++
+			}
+		}
+		h.finish(spanCtx, pipeErr, map[string]interface{}{
+			"db.redis.failed_commands": failed,
+		})
+		return pipeErr
+	}
+}
+
+func (h *Hook) finish(ctx context.Context, err error, attrs map[string]interface{}) {
+	if !isFailure(err) {
+		err = nil
+	}
+
+	// The command context may already be cancelled; the span still has to end
+	h.client.FinishSpan(context.WithoutCancel(ctx), goinsight.WithSpanError(err), goinsight.WithAttributes(attrs))
+}
+
+// isFailure reports whether err is a real failure; redis.Nil only means the
+// key doesn't exist
+func isFailure(err error) bool {
+	return err != nil && !errors.Is(err, redis.Nil)
+}
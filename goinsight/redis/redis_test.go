@@ -0,0 +1,178 @@
+package goinsightredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+	"github.com/redis/go-redis/v9"
+)
+
+// recorder is an exporter keeping the spans it is given, in order
+type recorder struct {
+	mu    sync.Mutex
+	spans []goinsight.Span
+	ends  map[string]goinsight.SpanEnd
+}
+
+func (r *recorder) ExportLog(context.Context, goinsight.LogEntry) error     { return nil }
+func (r *recorder) ExportMetric(context.Context, goinsight.Metric) error    { return nil }
+func (r *recorder) EndTrace(context.Context, string) error                  { return nil }
+func (r *recorder) ExportError(context.Context, goinsight.ErrorEvent) error { return nil }
+func (r *recorder) Shutdown(context.Context) error                          { return nil }
+
+func (r *recorder) StartTrace(context.Context, goinsight.Trace) (string, error) {
+	return "trace-1", nil
+}
+
+func (r *recorder) StartSpan(_ context.Context, span goinsight.Span) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+	return fmt.Sprintf("span-%d", len(r.spans)), nil
+}
+
+func (r *recorder) EndSpan(_ context.Context, spanID string, end goinsight.SpanEnd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ends == nil {
+		r.ends = make(map[string]goinsight.SpanEnd)
+	}
+	r.ends[spanID] = end
+	return nil
+}
+
+// startTrace returns a client exporting to a new recorder and a context
+// carrying one of its traces. The trace's root span is span-1.
+func startTrace(t *testing.T) (context.Context, *goinsight.Client, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	client, err := goinsight.NewClient(goinsight.WithServiceName("redis-test"), goinsight.WithExporter(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	ctx, _, err := client.StartTrace(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx, client, rec
+}
+
+func TestProcessHook(t *testing.T) {
+	errDown := errors.New("connection reset")
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{"success", nil, false},
+		{"missing key", redis.Nil, false},
+		{"failure", errDown, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, client, rec := startTrace(t)
+			process := NewHook(client).ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+				cmd.SetErr(tt.err)
+				return tt.err
+			})
+
+			if err := process(ctx, redis.NewStringCmd(ctx, "get", "session:42")); err != tt.err {
+				t.Fatalf("hook returned %v, want %v", err, tt.err)
+			}
+
+			if len(rec.spans) != 2 {
+				t.Fatalf("got %d spans, want the root and one command", len(rec.spans))
+			}
+			span := rec.spans[1]
+			if span.Operation != "redis.GET" || span.Kind != goinsight.SpanKindClient {
+				t.Errorf("span = %s %s, want client redis.GET", span.Kind, span.Operation)
+			}
+			if span.Attributes["db.operation"] != "GET" {
+				t.Errorf("db.operation = %v", span.Attributes["db.operation"])
+			}
+			for _, value := range span.Attributes {
+				if value == "session:42" {
+					t.Error("command arguments were recorded")
+				}
+			}
+			end, ok := rec.ends["span-2"]
+			if !ok {
+				t.Fatal("command span was not ended")
+			}
+			if (end.Status == goinsight.SpanStatusError) != tt.wantErr {
+				t.Errorf("status %q, error %q", end.Status, end.Error)
+			}
+		})
+	}
+}
+
+func TestProcessPipelineHook(t *testing.T) {
+	ctx, client, rec := startTrace(t)
+	errDown := errors.New("WRONGTYPE")
+
+	cmds := []redis.Cmder{
+		redis.NewStatusCmd(ctx, "set", "a", "1"),
+		redis.NewStringCmd(ctx, "get", "missing"),
+		redis.NewIntCmd(ctx, "incr", "a"),
+	}
+	for i := 0; i < maxPipelineCommands; i++ {
+		cmds = append(cmds, redis.NewIntCmd(ctx, "del", fmt.Sprint(i)))
+	}
+	process := NewHook(client).ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+		cmds[1].SetErr(redis.Nil)
+		cmds[2].SetErr(errDown)
+		return errDown
+	})
+
+	if err := process(ctx, cmds); err != errDown {
+		t.Fatalf("hook returned %v", err)
+	}
+
+	if len(rec.spans) != 2 {
+		t.Fatalf("got %d spans, want the root and one pipeline", len(rec.spans))
+	}
+	span := rec.spans[1]
+	if span.Operation != "redis.pipeline" {
+		t.Errorf("operation %q, want redis.pipeline", span.Operation)
+	}
+	if size := span.Attributes["db.redis.pipeline_size"]; size != len(cmds) {
+		t.Errorf("pipeline_size = %v, want %d", size, len(cmds))
+	}
+	names, _ := span.Attributes["db.redis.commands"].([]string)
+	if len(names) != maxPipelineCommands || names[0] != "SET" || names[2] != "INCR" {
+		t.Errorf("commands = %v", names)
+	}
+
+	end := rec.ends["span-2"]
+	if end.Status != goinsight.SpanStatusError {
+		t.Errorf("status %q, want error", end.Status)
+	}
+	// redis.Nil for the missing key isn't counted
+	if failed := end.Attributes["db.redis.failed_commands"]; failed != 1 {
+		t.Errorf("failed_commands = %v, want 1", failed)
+	}
+}
+
+func TestHookWithoutTrace(t *testing.T) {
+	_, client, rec := startTrace(t)
+	ctx := context.Background()
+
+	called := false
+	process := NewHook(client).ProcessHook(func(context.Context, redis.Cmder) error {
+		called = true
+		return nil
+	})
+	if err := process(ctx, redis.NewStringCmd(ctx, "get", "k")); err != nil || !called {
+		t.Fatalf("hook returned %v, called %v", err, called)
+	}
+	if len(rec.spans) != 1 {
+		t.Errorf("got %d spans for a command without a trace", len(rec.spans)-1)
+	}
+}
@@ -0,0 +1,176 @@
+package goinsight
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ServerInstrumentation implements the request instrumentation shared by the
+// framework middlewares. An integration calls Start before running the
+// handler and Finish once it returns.
+type ServerInstrumentation struct {
+	client *Client
+	cfg    *middlewareConfig
+	source MetricSource
+}
+
+// NewServerInstrumentation returns request instrumentation that reports
+// metrics under source. Framework integrations build one per middleware.
+func (c *Client) NewServerInstrumentation(source MetricSource, opts ...MiddlewareOption) *ServerInstrumentation {
+	return &ServerInstrumentation{
+		client: c,
+		cfg:    newMiddlewareConfig(opts),
+		source: source,
+	}
+}
+
+// Skip reports whether requests to the URL path should not be instrumented
+func (s *ServerInstrumentation) Skip(path string) bool {
+	return s.cfg.skip(path)
+}
+
+// Start begins the trace for a request. When the trace can't be created it
+// returns ctx unchanged and a nil TraceContext; the request is still measured.
+func (s *ServerInstrumentation) Start(ctx context.Context, method, route string) (context.Context, *TraceContext) {
+	newCtx, traceCtx, err := s.client.StartTrace(ctx, s.cfg.spanName(method, route), WithSpanKind(SpanKindServer))
+	if err != nil {
+		return ctx, nil
+	}
+	return newCtx, traceCtx
+}
+
+// HeaderGetter reads a header value. http.Header satisfies it; use HeaderFunc
+// to adapt other header types.
+type HeaderGetter interface {
+	Get(key string) string
+}
+
+// HeaderFunc adapts a lookup function to HeaderGetter
+type HeaderFunc func(key string) string
+
+// Get returns f(key)
+func (f HeaderFunc) Get(key string) string {
+	return f(key)
+}
+
+// CompletedRequest describes a request after its handler returned
+type CompletedRequest struct {
+	Method         string
+	Route          string
+	StatusCode     int
+	Duration       time.Duration
+	RequestHeader  HeaderGetter
+	ResponseHeader HeaderGetter
+
+	// Locals looks up the router's request-local values (gin.Context.Get,
+	// echo.Context.Get, fiber.Ctx.Locals) for the user ID extractor. Optional.
+	Locals func(key string) interface{}
+}
+
+// Finish snapshots req and exports the request's metric, completion log and
+// trace asynchronously. ctx must be the request context derived from Start.
+// Finish copies everything it needs before returning, so the framework may
+// recycle its request state right after.
+func (s *ServerInstrumentation) Finish(ctx context.Context, traceCtx *TraceContext, req CompletedRequest) {
+	locals := ctx
+	if req.Locals != nil {
+		locals = localsContext{Context: ctx, get: req.Locals}
+	}
+
+	rec := requestRecord{
+		ctx:             ctx,
+		trace:           traceCtx,
+		method:          req.Method,
+		path:            req.Route,
+		statusCode:      req.StatusCode,
+		level:           s.cfg.statusLevel(req.StatusCode),
+		duration:        req.Duration,
+		end:             time.Now(),
+		userID:          s.cfg.extractUserID(locals),
+		source:          s.source,
+		requestHeaders:  captureHeaders(s.cfg.requestHeaders, req.RequestHeader),
+		responseHeaders: captureHeaders(s.cfg.responseHeaders, req.ResponseHeader),
+	}
+	if req.RequestHeader != nil {
+		rec.userAgent = req.RequestHeader.Get("User-Agent")
+		rec.requestID = req.RequestHeader.Get("X-Request-ID")
+	}
+
+	// Export metric, log and trace asynchronously
+	go s.client.exportRequest(rec)
+}
+
+// requestRecord is an immutable snapshot of a completed HTTP request. Finish
+// takes it synchronously, before the framework is free to recycle its
+// request context, and hands it to exportRequest.
+type requestRecord struct {
+	ctx        context.Context
+	trace      *TraceContext
+	method     string
+	path       string
+	statusCode int
+	level      string
+	duration   time.Duration
+	end        time.Time
+	userAgent  string
+	requestID  string
+	userID     string
+	source     MetricSource
+
+	requestHeaders  map[string]string
+	responseHeaders map[string]string
+}
+
+// exportRequest sends the metric and completion log for a request and
+// finishes its trace. It runs on its own goroutine and only reads rec.
+func (c *Client) exportRequest(rec requestRecord) {
+	// Detach from the request so cancellation after the handler returns
+	// doesn't abort the export; every send is still bounded by the client timeout
+	ctx := context.WithoutCancel(rec.ctx)
+
+	metric := Metric{
+		ServiceName: c.serviceName,
+		Path:        rec.path,
+		Method:      rec.method,
+		StatusCode:  rec.statusCode,
+		Duration:    float64(rec.duration.Nanoseconds()) / 1e6, // Convert to milliseconds
+		Source:      rec.source,
+		RequestID:   rec.requestID,
+	}
+	c.SendMetricContext(ctx, metric)
+
+	metadata := map[string]interface{}{
+		"method":      rec.method,
+		"path":        rec.path,
+		"status_code": rec.statusCode,
+		"duration_ms": rec.duration.Milliseconds(),
+		"user_agent":  rec.userAgent,
+	}
+	if rec.userID != "" {
+		metadata["user_id"] = rec.userID
+	}
+	if len(rec.requestHeaders) > 0 {
+		metadata["request_headers"] = rec.requestHeaders
+	}
+	if len(rec.responseHeaders) > 0 {
+		metadata["response_headers"] = rec.responseHeaders
+	}
+	c.logAt(ctx, rec.end, rec.level, fmt.Sprintf("Request completed: %s %s", rec.method, rec.path), metadata)
+
+	if rec.trace != nil && !rec.trace.unsampled {
+		end := SpanEnd{
+			EndTime:  rec.end,
+			Duration: float64(rec.duration.Nanoseconds()) / 1e6,
+			Status:   SpanStatusOK,
+		}
+		// Client errors are the caller's fault; only 5xx fail a server span
+		if rec.statusCode >= 500 {
+			end.Status = SpanStatusError
+			end.Error = fmt.Sprintf("HTTP %d %s", rec.statusCode, http.StatusText(rec.statusCode))
+		}
+		c.endSpan(ctx, rec.trace.SpanID, end)
+		c.endTrace(ctx, rec.trace.TraceID)
+	}
+}
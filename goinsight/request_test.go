@@ -0,0 +1,64 @@
+package goinsight
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServerSpanStatus(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		want       string
+	}{
+		{http.StatusOK, SpanStatusOK},
+		{http.StatusNotFound, SpanStatusOK},
+		{http.StatusInternalServerError, SpanStatusError},
+		{http.StatusServiceUnavailable, SpanStatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.statusCode), func(t *testing.T) {
+			exporter := &recordingExporter{}
+			client, err := NewClient(WithServiceName("request-test"), WithExporter(exporter))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			inst := client.NewServerInstrumentation(MetricSource{Language: "go", Framework: "test"})
+			ctx, traceCtx := inst.Start(context.Background(), "GET", "/orders/{id}")
+			if traceCtx == nil {
+				t.Fatal("Start didn't create a trace")
+			}
+			inst.Finish(ctx, traceCtx, CompletedRequest{
+				Method:     "GET",
+				Route:      "/orders/{id}",
+				StatusCode: tt.statusCode,
+				Duration:   time.Millisecond,
+			})
+
+			// Finish exports in the background
+			var end SpanEnd
+			for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+				exporter.mu.Lock()
+				var ok bool
+				end, ok = exporter.ends[traceCtx.SpanID]
+				exporter.mu.Unlock()
+				if ok {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("root span was not ended")
+				}
+			}
+			if end.Status != tt.want {
+				t.Errorf("status %q, want %q", end.Status, tt.want)
+			}
+			if (end.Error != "") != (tt.want == SpanStatusError) {
+				t.Errorf("error %q with status %q", end.Error, end.Status)
+			}
+		})
+	}
+}
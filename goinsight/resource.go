@@ -0,0 +1,241 @@
+package goinsight
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Resource attributes describing the process that sends telemetry. Keys
+// follow the OpenTelemetry semantic conventions, so values read from
+// OTEL_RESOURCE_ATTRIBUTES line up with detected ones.
+const (
+	AttrServiceName    = "service.name"
+	AttrServiceVersion = "service.version"
+	AttrEnvironment    = "deployment.environment"
+	AttrHostName       = "host.name"
+	AttrProcessPID     = "process.pid"
+	AttrContainerID    = "container.id"
+	AttrK8sNamespace   = "k8s.namespace.name"
+	AttrK8sPodName     = "k8s.pod.name"
+	AttrK8sPodUID      = "k8s.pod.uid"
+	AttrK8sNodeName    = "k8s.node.name"
+)
+
+const sdkModule = "github.com/NathanSanchezDev/go-insight-go-sdk"
+
+// Paths read while detecting Kubernetes and container metadata. The podinfo
+// directory is where the downward API volume is conventionally mounted;
+// GOINSIGHT_PODINFO_DIR (or GO_INSIGHT_PODINFO_DIR) overrides it.
+const (
+	serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	defaultPodInfoDir       = "/etc/podinfo"
+	cgroupFile              = "/proc/self/cgroup"
+)
+
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// Resource holds the attributes attached to every log, metric and trace a
+// client sends
+type Resource map[string]string
+
+// DetectResource builds the resource for config. Detected values come from
+// the build info, host, container and Kubernetes environment; they are
+// overridden by OTEL_RESOURCE_ATTRIBUTES, then GOINSIGHT_ENV (or its alias
+// GO_INSIGHT_ENV), then by config itself.
+func DetectResource(config Config) Resource {
+	res := Resource{
+		"telemetry.sdk.name":      "go-insight-go-sdk",
+		"telemetry.sdk.language":  "go",
+		"process.runtime.version": runtime.Version(),
+		AttrProcessPID:            strconv.Itoa(os.Getpid()),
+	}
+
+	if host, err := os.Hostname(); err == nil {
+		res[AttrHostName] = host
+	}
+	detectBuildInfo(res)
+	if id := detectContainerID(); id != "" {
+		res[AttrContainerID] = id
+	}
+	detectKubernetes(res)
+
+	for k, v := range parseResourceAttributes(os.Getenv("OTEL_RESOURCE_ATTRIBUTES")) {
+		res[k] = v
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		res[AttrServiceName] = name
+	}
+	if env := getenvAny("GOINSIGHT_ENV", "GO_INSIGHT_ENV"); env != "" {
+		res[AttrEnvironment] = env
+	}
+
+	for k, v := range config.ResourceAttributes {
+		res[k] = v
+	}
+	if config.ServiceName != "" {
+		res[AttrServiceName] = config.ServiceName
+	}
+	if config.ServiceVersion != "" {
+		res[AttrServiceVersion] = config.ServiceVersion
+	}
+	if config.Environment != "" {
+		res[AttrEnvironment] = config.Environment
+	}
+
+	return res
+}
+
+// detectBuildInfo records the main module's version and VCS revision, and
+// the SDK version linked into the binary
+func detectBuildInfo(res Resource) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		res[AttrServiceVersion] = v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			res["vcs.revision"] = s.Value
+		}
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == sdkModule {
+			res["telemetry.sdk.version"] = dep.Version
+		}
+	}
+}
+
+// ModuleVersion reports the version of the module at path linked into the
+// binary, following replacements, or "unknown". Framework integrations use
+// it for MetricSource.Version when the framework has no version constant.
+func ModuleVersion(path string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != path {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
+
+// detectContainerID reads the container ID from the cgroup paths of the
+// current process, or returns "" outside a container
+func detectContainerID() string {
+	f, err := os.Open(cgroupFile)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if id := containerIDPattern.FindString(scanner.Text()); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// detectKubernetes records pod metadata exposed through downward API
+// environment variables and files. It does nothing outside Kubernetes.
+func detectKubernetes(res Resource) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return
+	}
+
+	// The pod name is the hostname unless the pod spec overrides it
+	if host := os.Getenv("HOSTNAME"); host != "" {
+		res[AttrK8sPodName] = host
+	}
+	if ns := readTrimmed(serviceAccountNamespace); ns != "" {
+		res[AttrK8sNamespace] = ns
+	}
+
+	dir := getenvAny("GOINSIGHT_PODINFO_DIR", "GO_INSIGHT_PODINFO_DIR")
+	if dir == "" {
+		dir = defaultPodInfoDir
+	}
+	files := map[string]string{
+		"name":      AttrK8sPodName,
+		"namespace": AttrK8sNamespace,
+		"uid":       AttrK8sPodUID,
+		"node":      AttrK8sNodeName,
+	}
+	for file, attr := range files {
+		if v := readTrimmed(filepath.Join(dir, file)); v != "" {
+			res[attr] = v
+		}
+	}
+
+	// Later variables win, so the K8S_ prefixed names take precedence
+	envs := []struct{ name, attr string }{
+		{"POD_NAME", AttrK8sPodName},
+		{"POD_NAMESPACE", AttrK8sNamespace},
+		{"POD_UID", AttrK8sPodUID},
+		{"NODE_NAME", AttrK8sNodeName},
+		{"K8S_POD_NAME", AttrK8sPodName},
+		{"K8S_NAMESPACE", AttrK8sNamespace},
+		{"K8S_POD_UID", AttrK8sPodUID},
+		{"K8S_NODE_NAME", AttrK8sNodeName},
+	}
+	for _, env := range envs {
+		if v := os.Getenv(env.name); v != "" {
+			res[env.attr] = v
+		}
+	}
+}
+
+func readTrimmed(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// parseResourceAttributes parses the OTEL_RESOURCE_ATTRIBUTES format: comma
+// separated key=value pairs with percent-encoded values. Malformed pairs are
+// skipped.
+func parseResourceAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if decoded, err := url.PathUnescape(v); err == nil {
+			v = decoded
+		}
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// getenvAny returns the value of the first of names that is set and not empty
+func getenvAny(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,33 @@
+package goinsight
+
+import "testing"
+
+func TestDetectResourceEnvironment(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		config string
+		want   string
+	}{
+		{"GOINSIGHT_ENV", map[string]string{"GOINSIGHT_ENV": "staging"}, "", "staging"},
+		{"alias", map[string]string{"GO_INSIGHT_ENV": "staging"}, "", "staging"},
+		{"GOINSIGHT_ENV wins over alias", map[string]string{"GOINSIGHT_ENV": "staging", "GO_INSIGHT_ENV": "qa"}, "", "staging"},
+		{"config wins", map[string]string{"GOINSIGHT_ENV": "staging"}, "production", "production"},
+		{"unset", nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOINSIGHT_ENV", "")
+			t.Setenv("GO_INSIGHT_ENV", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			res := DetectResource(Config{ServiceName: "checkout", Environment: tt.config})
+			if got := res[AttrEnvironment]; got != tt.want {
+				t.Errorf("%s = %q, want %q", AttrEnvironment, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package goinsight
+
+import (
+	"context"
+	"math/rand"
+)
+
+// Sampler decides whether a new trace is recorded. Spans follow the
+// decision made for their trace; traces continued from a remote parent are
+// always recorded.
+type Sampler interface {
+	ShouldSample(ctx context.Context, operation string) bool
+}
+
+// SamplerFunc adapts a function to Sampler
+type SamplerFunc func(ctx context.Context, operation string) bool
+
+// ShouldSample returns f(ctx, operation)
+func (f SamplerFunc) ShouldSample(ctx context.Context, operation string) bool {
+	return f(ctx, operation)
+}
+
+// AlwaysSample records every trace. It is the default.
+func AlwaysSample() Sampler {
+	return SamplerFunc(func(context.Context, string) bool { return true })
+}
+
+// NeverSample records no traces
+func NeverSample() Sampler {
+	return SamplerFunc(func(context.Context, string) bool { return false })
+}
+
+// RatioSampler records the given fraction of traces, chosen at random. A
+// rate of 1 or more records every trace, 0 or less none.
+func RatioSampler(rate float64) Sampler {
+	switch {
+	case rate >= 1:
+		return AlwaysSample()
+	case rate <= 0:
+		return NeverSample()
+	}
+	return SamplerFunc(func(context.Context, string) bool {
+		return rand.Float64() < rate
+	})
+}
@@ -0,0 +1,165 @@
+// Package goinsightsarama propagates Go-Insight traces through IBM/sarama
+// producer and consumer messages
+package goinsightsarama
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+)
+
+// ProducerCarrier adapts the headers of a sarama.ProducerMessage to
+// goinsight.Carrier
+type ProducerCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+var _ goinsight.Carrier = ProducerCarrier{}
+
+// NewProducerCarrier returns a ProducerCarrier over msg's headers
+func NewProducerCarrier(msg *sarama.ProducerMessage) ProducerCarrier {
+	return ProducerCarrier{msg: msg}
+}
+
+// Get returns the value of the first header named key
+func (c ProducerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set replaces the header named key, or appends it. msg gets a new header
+// slice, so one shared with other messages isn't modified.
+func (c ProducerCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			headers := append([]sarama.RecordHeader(nil), c.msg.Headers...)
+			headers[i].Value = []byte(value)
+			c.msg.Headers = headers
+			return
+		}
+	}
+	n := len(c.msg.Headers)
+	c.msg.Headers = append(c.msg.Headers[:n:n], sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+// ConsumerCarrier adapts the headers of a sarama.ConsumerMessage to
+// goinsight.Carrier
+type ConsumerCarrier struct {
+	msg *sarama.ConsumerMessage
+}
+
+var _ goinsight.Carrier = ConsumerCarrier{}
+
+// NewConsumerCarrier returns a ConsumerCarrier over msg's headers
+func NewConsumerCarrier(msg *sarama.ConsumerMessage) ConsumerCarrier {
+	return ConsumerCarrier{msg: msg}
+}
+
+// Get returns the value of the first header named key
+func (c ConsumerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set replaces the header named key, or appends it. Like
+// ProducerCarrier.Set, it leaves the headers msg had untouched.
+func (c ConsumerCarrier) Set(key, value string) {
+	header := &sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+	for i, h := range c.msg.Headers {
+		if h != nil && string(h.Key) == key {
+			headers := append([]*sarama.RecordHeader(nil), c.msg.Headers...)
+			headers[i] = header
+			c.msg.Headers = headers
+			return
+		}
+	}
+	n := len(c.msg.Headers)
+	c.msg.Headers = append(c.msg.Headers[:n:n], header)
+}
+
+// SyncProducer wraps a sarama.SyncProducer with context-aware sends that
+// record a "kafka.send" span and inject the trace into each message
+//
+//	p := goinsightsarama.NewSyncProducer(client, producer)
+//	partition, offset, err := p.SendMessage(ctx, msg)
+type SyncProducer struct {
+	sarama.SyncProducer
+	client *goinsight.Client
+}
+
+// NewSyncProducer returns a SyncProducer reporting to client
+func NewSyncProducer(client *goinsight.Client, p sarama.SyncProducer) *SyncProducer {
+	return &SyncProducer{SyncProducer: p, client: client}
+}
+
+// SendMessage sends msg with the trace context of ctx in its headers and
+// records the partition and offset it was written to. The message is only
+// traced when ctx carries a trace.
+func (p *SyncProducer) SendMessage(ctx context.Context, msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	spanCtx, finish := p.client.StartProducerSpan(ctx, "kafka.send", map[string]interface{}{
+		"messaging.system":      "kafka",
+		"messaging.operation":   "send",
+		"messaging.destination": msg.Topic,
+	})
+
+	goinsight.Inject(spanCtx, NewProducerCarrier(msg))
+	partition, offset, err = p.SyncProducer.SendMessage(msg)
+
+	finish(err, goinsight.WithAttributes(map[string]interface{}{
+		"messaging.kafka.partition": partition,
+		"messaging.kafka.offset":    offset,
+	}))
+	return partition, offset, err
+}
+
+// SendMessages sends msgs as one batch under a single "kafka.send" span,
+// with its trace context in every message's headers
+func (p *SyncProducer) SendMessages(ctx context.Context, msgs []*sarama.ProducerMessage) error {
+	attrs := map[string]interface{}{
+		"messaging.system":     "kafka",
+		"messaging.operation":  "send",
+		"messaging.batch_size": len(msgs),
+	}
+	if len(msgs) > 0 {
+		attrs["messaging.destination"] = msgs[0].Topic
+	}
+
+	spanCtx, finish := p.client.StartProducerSpan(ctx, "kafka.send", attrs)
+	for _, msg := range msgs {
+		goinsight.Inject(spanCtx, NewProducerCarrier(msg))
+	}
+
+	err := p.SyncProducer.SendMessages(msgs)
+	finish(err)
+	return err
+}
+
+// Process runs fn for a message claimed by a consumer group inside a
+// "kafka.process" span, a child of the producer's span when msg carries
+// one. Mark the message once fn has handled it.
+//
+//	func (h handler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+//		for msg := range claim.Messages() {
+//			goinsightsarama.Process(sess.Context(), client, msg, h.handle)
+//			sess.MarkMessage(msg, "")
+//		}
+//		return nil
+//	}
+func Process(ctx context.Context, client *goinsight.Client, msg *sarama.ConsumerMessage, fn func(ctx context.Context) error) error {
+	return client.ProcessMessage(ctx, NewConsumerCarrier(msg), "kafka.process", map[string]interface{}{
+		"messaging.system":          "kafka",
+		"messaging.operation":       "process",
+		"messaging.destination":     msg.Topic,
+		"messaging.kafka.partition": msg.Partition,
+		"messaging.kafka.offset":    msg.Offset,
+	}, fn)
+}
@@ -0,0 +1,66 @@
+package goinsightsarama
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/NathanSanchezDev/go-insight-go-sdk/goinsight"
+)
+
+func TestProducerCarrierSetGet(t *testing.T) {
+	shared := make([]sarama.RecordHeader, 1, 4)
+	shared[0] = sarama.RecordHeader{Key: []byte("tenant"), Value: []byte("acme")}
+	msg := &sarama.ProducerMessage{Headers: shared}
+	carrier := NewProducerCarrier(msg)
+
+	carrier.Set(goinsight.TraceIDHeader, "trace-1")
+	carrier.Set(goinsight.TraceIDHeader, "trace-2")
+	if got := carrier.Get(goinsight.TraceIDHeader); got != "trace-2" {
+		t.Errorf("Get after overwrite = %q", got)
+	}
+	if len(msg.Headers) != 2 {
+		t.Errorf("overwrite appended: %d headers", len(msg.Headers))
+	}
+	if extra := shared[:2]; extra[1].Key != nil {
+		t.Errorf("Set wrote into the caller's array: %+v", extra[1])
+	}
+
+	carrier.Set("tenant", "globex")
+	if string(shared[0].Value) != "acme" {
+		t.Errorf("overwrite modified the caller's slice: %q", shared[0].Value)
+	}
+	if got := carrier.Get("tenant"); got != "globex" {
+		t.Errorf("Get after overwrite = %q", got)
+	}
+	if got := carrier.Get("missing"); got != "" {
+		t.Errorf("missing header = %q", got)
+	}
+}
+
+func TestConsumerCarrierSetGet(t *testing.T) {
+	tenant := &sarama.RecordHeader{Key: []byte("tenant"), Value: []byte("acme")}
+	shared := []*sarama.RecordHeader{nil, tenant}
+	msg := &sarama.ConsumerMessage{Headers: shared}
+	carrier := NewConsumerCarrier(msg)
+
+	if got := carrier.Get("tenant"); got != "acme" {
+		t.Errorf("existing header = %q", got)
+	}
+
+	carrier.Set(goinsight.TraceIDHeader, "trace-1")
+	carrier.Set(goinsight.TraceIDHeader, "trace-2")
+	if got := carrier.Get(goinsight.TraceIDHeader); got != "trace-2" {
+		t.Errorf("Get after overwrite = %q", got)
+	}
+	if len(msg.Headers) != 3 {
+		t.Errorf("overwrite appended: %d headers", len(msg.Headers))
+	}
+
+	carrier.Set("tenant", "globex")
+	if string(tenant.Value) != "acme" || shared[1] != tenant {
+		t.Error("overwrite modified the caller's headers")
+	}
+	if got := carrier.Get("tenant"); got != "globex" {
+		t.Errorf("Get after overwrite = %q", got)
+	}
+}
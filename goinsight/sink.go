@@ -0,0 +1,267 @@
+package goinsight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink event kinds
+const (
+	SinkLog        = "log"
+	SinkMetric     = "metric"
+	SinkTraceStart = "trace_start"
+	SinkTraceEnd   = "trace_end"
+	SinkSpanStart  = "span_start"
+	SinkSpanEnd    = "span_end"
+	SinkError      = "error"
+)
+
+// SinkEvent is a copy of what the client exported, or tried to
+type SinkEvent struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+
+	// ID is the trace or span ID, when the event has one
+	ID string `json:"id,omitempty"`
+
+	// Record is the LogEntry, Metric, Trace, Span, SpanEnd or ErrorEvent
+	Record interface{} `json:"record,omitempty"`
+
+	// ExportError is why the export failed, if it did
+	ExportError string `json:"export_error,omitempty"`
+}
+
+// Sink writes telemetry locally, next to or instead of Go-Insight. Sinks
+// must be safe for concurrent use; their errors are ignored.
+type Sink interface {
+	Write(event SinkEvent) error
+	Close() error
+}
+
+// Sink modes
+const (
+	// SinkModeTee writes everything the client exports
+	SinkModeTee = "tee"
+
+	// SinkModeFallback writes only what fails to export
+	SinkModeFallback = "fallback"
+)
+
+// sinkFromConfig opens the sink described by config, or returns nil when
+// it has none
+func sinkFromConfig(config Config) (Sink, error) {
+	switch config.SinkType {
+	case "":
+		return nil, nil
+	case "console":
+		return NewConsoleSink(os.Stderr), nil
+	case "json":
+		return NewJSONSink(os.Stderr), nil
+	case "file":
+		sink, err := NewFileSink(config.SinkPath, config.SinkMaxSize, config.SinkMaxFiles)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	}
+	return nil, &ConfigError{Field: "SinkType", Reason: fmt.Sprintf("unknown sink %q", config.SinkType)}
+}
+
+// sinkExporter copies exports to sinks: tee sinks get every one, fallback
+// sinks the failed ones
+type sinkExporter struct {
+	next     Exporter
+	tee      []Sink
+	fallback []Sink
+}
+
+var _ Exporter = (*sinkExporter)(nil)
+
+func (e *sinkExporter) write(kind, id string, record interface{}, err error) {
+	if len(e.tee) == 0 && (err == nil || len(e.fallback) == 0) {
+		return
+	}
+	event := SinkEvent{Kind: kind, Time: time.Now(), ID: id, Record: record}
+	if err != nil {
+		event.ExportError = err.Error()
+	}
+	for _, s := range e.tee {
+		s.Write(event)
+	}
+	if err != nil {
+		for _, s := range e.fallback {
+			s.Write(event)
+		}
+	}
+}
+
+func (e *sinkExporter) ExportLog(ctx context.Context, entry LogEntry) error {
+	err := e.next.ExportLog(ctx, entry)
+	e.write(SinkLog, "", entry, err)
+	return err
+}
+
+func (e *sinkExporter) ExportMetric(ctx context.Context, metric Metric) error {
+	err := e.next.ExportMetric(ctx, metric)
+	e.write(SinkMetric, "", metric, err)
+	return err
+}
+
+func (e *sinkExporter) StartTrace(ctx context.Context, trace Trace) (string, error) {
+	id, err := e.next.StartTrace(ctx, trace)
+	e.write(SinkTraceStart, id, trace, err)
+	return id, err
+}
+
+func (e *sinkExporter) StartSpan(ctx context.Context, span Span) (string, error) {
+	id, err := e.next.StartSpan(ctx, span)
+	e.write(SinkSpanStart, id, span, err)
+	return id, err
+}
+
+func (e *sinkExporter) EndSpan(ctx context.Context, spanID string, end SpanEnd) error {
+	err := e.next.EndSpan(ctx, spanID, end)
+	e.write(SinkSpanEnd, spanID, end, err)
+	return err
+}
+
+func (e *sinkExporter) EndTrace(ctx context.Context, traceID string) error {
+	err := e.next.EndTrace(ctx, traceID)
+	e.write(SinkTraceEnd, traceID, nil, err)
+	return err
+}
+
+func (e *sinkExporter) ExportError(ctx context.Context, event ErrorEvent) error {
+	err := e.next.ExportError(ctx, event)
+	e.write(SinkError, "", event, err)
+	return err
+}
+
+// Shutdown shuts the wrapped exporter down, then closes the sinks
+func (e *sinkExporter) Shutdown(ctx context.Context) error {
+	errs := []error{e.next.Shutdown(ctx)}
+	for _, s := range e.tee {
+		errs = append(errs, s.Close())
+	}
+	for _, s := range e.fallback {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// JSONSink writes events as JSON lines, e.g. to os.Stderr
+type JSONSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONSink returns a sink writing one JSON object per event to w
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{enc: json.NewEncoder(w)}
+}
+
+func (s *JSONSink) Write(event SinkEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(event)
+}
+
+// Close does nothing; the writer belongs to the caller
+func (s *JSONSink) Close() error {
+	return nil
+}
+
+// ConsoleSink writes events as readable lines for local development
+type ConsoleSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewConsoleSink returns a sink writing one line per event to w
+func NewConsoleSink(w io.Writer) *ConsoleSink {
+	return &ConsoleSink{w: w}
+}
+
+func (s *ConsoleSink) Write(event SinkEvent) error {
+	var b strings.Builder
+	b.WriteString(event.Time.Format("15:04:05.000"))
+
+	switch r := event.Record.(type) {
+	case LogEntry:
+		fmt.Fprintf(&b, " %-6s %s", r.LogLevel, r.Message)
+		writeFields(&b, r.Metadata)
+		writeID(&b, "trace", r.TraceID)
+		writeID(&b, "span", r.SpanID)
+	case Metric:
+		fmt.Fprintf(&b, " METRIC %s %s %d %.1fms", r.Method, r.Path, r.StatusCode, r.Duration)
+		writeFields(&b, r.Metadata)
+	case Trace:
+		b.WriteString(" TRACE  start")
+		writeID(&b, "trace", event.ID)
+	case Span:
+		fmt.Fprintf(&b, " SPAN   start %s", r.Operation)
+		if r.Kind != "" {
+			fmt.Fprintf(&b, " (%s)", r.Kind)
+		}
+		writeFields(&b, r.Attributes)
+		writeID(&b, "trace", r.TraceID)
+		writeID(&b, "span", event.ID)
+	case SpanEnd:
+		fmt.Fprintf(&b, " SPAN   end %.1fms %s", r.Duration, r.Status)
+		if r.Error != "" {
+			fmt.Fprintf(&b, " error=%q", r.Error)
+		}
+		writeFields(&b, r.Attributes)
+		writeID(&b, "span", event.ID)
+	case ErrorEvent:
+		fmt.Fprintf(&b, " ERROR  %s x%d %s", r.Type, r.Count, r.Message)
+		writeID(&b, "fingerprint", r.Fingerprint)
+		writeID(&b, "trace", r.TraceID)
+	case nil:
+		// EndTrace carries no record
+		b.WriteString(" TRACE  end")
+		writeID(&b, "trace", event.ID)
+	default:
+		fmt.Fprintf(&b, " %s %v", event.Kind, r)
+	}
+	if event.ExportError != "" {
+		fmt.Fprintf(&b, " export_error=%q", event.ExportError)
+	}
+	b.WriteByte('\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := io.WriteString(s.w, b.String())
+	return err
+}
+
+// Close does nothing; the writer belongs to the caller
+func (s *ConsoleSink) Close() error {
+	return nil
+}
+
+// writeFields appends fields as sorted key=value pairs
+func writeFields(b *strings.Builder, fields map[string]interface{}) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, " %s=%v", k, fields[k])
+	}
+}
+
+func writeID(b *strings.Builder, name, id string) {
+	if id != "" {
+		fmt.Fprintf(b, " %s=%s", name, id)
+	}
+}
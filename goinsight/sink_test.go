@@ -0,0 +1,76 @@
+package goinsight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySink keeps the events written to it
+type memorySink struct {
+	mu     sync.Mutex
+	events []SinkEvent
+}
+
+func (s *memorySink) Write(event SinkEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+// gatedExporter holds every log export until release is closed
+type gatedExporter struct {
+	*recordingExporter
+	release chan struct{}
+}
+
+func (e *gatedExporter) ExportLog(ctx context.Context, entry LogEntry) error {
+	<-e.release
+	return e.recordingExporter.ExportLog(ctx, entry)
+}
+
+func TestQueueFullGoesToFallbackSink(t *testing.T) {
+	exporter := &gatedExporter{recordingExporter: &recordingExporter{}, release: make(chan struct{})}
+	sink := &memorySink{}
+	client, err := NewClient(
+		WithConfig(Config{ServiceName: "sink-test", BatchSize: 1, FlushInterval: time.Hour, QueueSize: 1}),
+		WithExporter(exporter),
+		WithFallbackSink(sink),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var refused []string
+	for i := 0; i < 10; i++ {
+		message := fmt.Sprintf("order %d", i)
+		if err := client.LogInfo(context.Background(), message); errors.Is(err, ErrQueueFull) {
+			refused = append(refused, message)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(exporter.release)
+	client.Close()
+
+	if len(refused) == 0 {
+		t.Fatal("the queue never filled up")
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.events) != len(refused) {
+		t.Fatalf("sink got %d events, want the %d refused logs", len(sink.events), len(refused))
+	}
+	for i, event := range sink.events {
+		entry, ok := event.Record.(LogEntry)
+		if event.Kind != SinkLog || !ok || entry.Message != refused[i] || event.ExportError != ErrQueueFull.Error() {
+			t.Errorf("event %d = %+v, want %q refused with %v", i, event, refused[i], ErrQueueFull)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package goinsight
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes the health of the client's delivery to Go-Insight. Counts
+// are totals since the client was created.
+type Stats struct {
+	// Queued counts logs, metrics and error events accepted by the batch
+	// queue. Dropped counts those refused because it was full, and
+	// everything the circuit breaker refused to send.
+	Queued  uint64 `json:"queued"`
+	Dropped uint64 `json:"dropped"`
+
+	// Sent and Failed count exporter calls that succeeded and failed,
+	// Retried the requests the HTTP exporter sent again
+	Sent    uint64 `json:"sent"`
+	Failed  uint64 `json:"failed"`
+	Retried uint64 `json:"retried"`
+
+	// QueueDepth is how many items wait in the batch queue, out of
+	// QueueCapacity. Both are zero when batching is disabled.
+	QueueDepth    int `json:"queue_depth"`
+	QueueCapacity int `json:"queue_capacity"`
+
+	// ExportLatency is the smoothed duration of exporter calls, weighting
+	// recent ones, and LastExportLatency the latest one's
+	ExportLatency     time.Duration `json:"export_latency_ns"`
+	LastExportLatency time.Duration `json:"last_export_latency_ns"`
+
+	// LastError is the latest export error, at LastErrorTime
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time"`
+
+	// CircuitState is the state of the circuit breaker, always
+	// CircuitClosed when it is disabled
+	CircuitState CircuitState `json:"circuit_state"`
+}
+
+// Stats returns a snapshot of the client's delivery counters
+func (c *Client) Stats() Stats {
+	s := c.stats.snapshot()
+	if c.batch != nil {
+		s.QueueDepth = len(c.batch.queue)
+		s.QueueCapacity = cap(c.batch.queue)
+	}
+	s.CircuitState = c.breaker.State()
+	return s
+}
+
+// PublishStats publishes the client's stats under name in expvar, so they
+// are served at /debug/vars. Like expvar.Publish, it panics when name is
+// already taken; expvar offers no way to unpublish it.
+func (c *Client) PublishStats(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return c.Stats()
+	}))
+}
+
+// StatsHandler serves the client's stats as JSON, usually mounted at
+// /debug/goinsight/stats next to LevelHandler. It has no authentication,
+// so expose it only on an internal listener.
+func (c *Client) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.Stats())
+	})
+}
+
+// clientStats holds the counters behind Stats. Its methods accept a nil
+// receiver so exporters built outside a client can share the code.
+type clientStats struct {
+	queued  atomic.Uint64
+	dropped atomic.Uint64
+	sent    atomic.Uint64
+	failed  atomic.Uint64
+	retried atomic.Uint64
+
+	mu            sync.Mutex
+	latency       time.Duration
+	lastLatency   time.Duration
+	lastError     string
+	lastErrorTime time.Time
+}
+
+// enqueued records the outcome of queueing an item
+func (s *clientStats) enqueued(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.dropped.Add(1)
+		return
+	}
+	s.queued.Add(1)
+}
+
+// exported records an exporter call that took d
+func (s *clientStats) exported(d time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	if err == nil {
+		s.sent.Add(1)
+	} else {
+		s.failed.Add(1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Smooth like TCP's round-trip estimate
+	if s.latency == 0 {
+		s.latency = d
+	} else {
+		s.latency += (d - s.latency) / 8
+	}
+	s.lastLatency = d
+	if err != nil {
+		s.lastError = err.Error()
+		s.lastErrorTime = time.Now()
+	}
+}
+
+// drop records an item that was never sent
+func (s *clientStats) drop() {
+	if s != nil {
+		s.dropped.Add(1)
+	}
+}
+
+func (s *clientStats) retry() {
+	if s != nil {
+		s.retried.Add(1)
+	}
+}
+
+func (s *clientStats) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return Stats{
+		Queued:            s.queued.Load(),
+		Dropped:           s.dropped.Load(),
+		Sent:              s.sent.Load(),
+		Failed:            s.failed.Load(),
+		Retried:           s.retried.Load(),
+		ExportLatency:     s.latency,
+		LastExportLatency: s.lastLatency,
+		LastError:         s.lastError,
+		LastErrorTime:     s.lastErrorTime,
+	}
+}
+
+// statsExporter records every call to the exporter it wraps
+type statsExporter struct {
+	next  Exporter
+	stats *clientStats
+}
+
+var _ Exporter = (*statsExporter)(nil)
+
+func (e *statsExporter) ExportLog(ctx context.Context, entry LogEntry) error {
+	start := time.Now()
+	err := e.next.ExportLog(ctx, entry)
+	e.stats.exported(time.Since(start), err)
+	return err
+}
+
+func (e *statsExporter) ExportMetric(ctx context.Context, metric Metric) error {
+	start := time.Now()
+	err := e.next.ExportMetric(ctx, metric)
+	e.stats.exported(time.Since(start), err)
+	return err
+}
+
+func (e *statsExporter) StartTrace(ctx context.Context, trace Trace) (string, error) {
+	start := time.Now()
+	id, err := e.next.StartTrace(ctx, trace)
+	e.stats.exported(time.Since(start), err)
+	return id, err
+}
+
+func (e *statsExporter) StartSpan(ctx context.Context, span Span) (string, error) {
+	start := time.Now()
+	id, err := e.next.StartSpan(ctx, span)
+	e.stats.exported(time.Since(start), err)
+	return id, err
+}
+
+func (e *statsExporter) EndSpan(ctx context.Context, spanID string, end SpanEnd) error {
+	start := time.Now()
+	err := e.next.EndSpan(ctx, spanID, end)
+	e.stats.exported(time.Since(start), err)
+	return err
+}
+
+func (e *statsExporter) EndTrace(ctx context.Context, traceID string) error {
+	start := time.Now()
+	err := e.next.EndTrace(ctx, traceID)
+	e.stats.exported(time.Since(start), err)
+	return err
+}
+
+func (e *statsExporter) ExportError(ctx context.Context, event ErrorEvent) error {
+	start := time.Now()
+	err := e.next.ExportError(ctx, event)
+	e.stats.exported(time.Since(start), err)
+	return err
+}
+
+func (e *statsExporter) Shutdown(ctx context.Context) error {
+	return e.next.Shutdown(ctx)
+}
+
+// StatsMetricPath is the Path of the metrics carrying the client's stats
+// when Config.StatsInterval is set
+const StatsMetricPath = "goinsight.sdk"
+
+// statsReporter sends the client's stats as a metric once per interval
+type statsReporter struct {
+	interval time.Duration
+	report   func()
+
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newStatsReporter(interval time.Duration, report func()) *statsReporter {
+	r := &statsReporter{
+		interval: interval,
+		report:   report,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *statsReporter) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.report()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// shutdown stops the reports, waiting until ctx is done at most
+func (r *statsReporter) shutdown(ctx context.Context) error {
+	r.once.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reportStats sends the client's stats as a metric with Path
+// StatsMetricPath, Method "INTERNAL" and the smoothed export latency as
+// its duration. It skips the metric processors.
+func (c *Client) reportStats() {
+	s := c.Stats()
+	metric := Metric{
+		ServiceName: c.serviceName,
+		Path:        StatsMetricPath,
+		Method:      "INTERNAL",
+		Duration:    float64(s.ExportLatency) / float64(time.Millisecond),
+		Source: MetricSource{
+			Language:  "go",
+			Framework: "go-insight-go-sdk",
+			Version:   c.resource["telemetry.sdk.version"],
+		},
+		Environment: c.resource[AttrEnvironment],
+		Metadata: map[string]interface{}{
+			"queued":         s.Queued,
+			"dropped":        s.Dropped,
+			"sent":           s.Sent,
+			"failed":         s.Failed,
+			"retried":        s.Retried,
+			"queue_depth":    s.QueueDepth,
+			"queue_capacity": s.QueueCapacity,
+			"circuit_state":  s.CircuitState,
+		},
+		Resource: c.resource,
+	}
+	if s.LastError != "" {
+		metric.Metadata["last_error"] = s.LastError
+	}
+	c.sendMetric(context.Background(), metric)
+}
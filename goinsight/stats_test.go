@@ -0,0 +1,110 @@
+package goinsight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStatsCountExports(t *testing.T) {
+	// One success, one retried 503, then a 400 that isn't retried
+	var requests atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1, 3:
+			w.WriteHeader(http.StatusOK)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.Error(w, "bad entry", http.StatusBadRequest)
+		}
+	})
+	client := newHTTPClient(t, handler, WithRetry(1, time.Millisecond))
+
+	ctx := context.Background()
+	for _, message := range []string{"sent", "retried", "rejected"} {
+		client.LogInfo(ctx, message)
+	}
+
+	s := client.Stats()
+	if s.Sent != 2 || s.Failed != 1 || s.Retried != 1 {
+		t.Errorf("sent %d, failed %d, retried %d, want 2, 1 and 1", s.Sent, s.Failed, s.Retried)
+	}
+	if s.LastError == "" || s.LastErrorTime.IsZero() {
+		t.Errorf("last error %q at %v, want the 400", s.LastError, s.LastErrorTime)
+	}
+	if s.ExportLatency <= 0 || s.LastExportLatency <= 0 {
+		t.Errorf("latency %v, last %v", s.ExportLatency, s.LastExportLatency)
+	}
+	if s.Queued != 0 || s.Dropped != 0 || s.QueueCapacity != 0 {
+		t.Errorf("queue counts %+v without batching", s)
+	}
+}
+
+func TestStatsCountDrops(t *testing.T) {
+	exporter := &gatedExporter{recordingExporter: &recordingExporter{}, release: make(chan struct{})}
+	client, err := NewClient(
+		WithConfig(Config{ServiceName: "stats-test", BatchSize: 1, FlushInterval: time.Hour, QueueSize: 1}),
+		WithExporter(exporter),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var queued, dropped uint64
+	for i := 0; i < 10; i++ {
+		if err := client.LogInfo(context.Background(), "order"); errors.Is(err, ErrQueueFull) {
+			dropped++
+		} else if err == nil {
+			queued++
+		}
+	}
+
+	s := client.Stats()
+	if s.Queued != queued || s.Dropped != dropped || dropped == 0 {
+		t.Errorf("queued %d, dropped %d, want %d and %d", s.Queued, s.Dropped, queued, dropped)
+	}
+	if s.QueueCapacity != 1 {
+		t.Errorf("queue capacity %d, want 1", s.QueueCapacity)
+	}
+
+	close(exporter.release)
+	client.Close()
+	if s := client.Stats(); s.Sent != queued {
+		t.Errorf("sent %d after Close, want the %d queued", s.Sent, queued)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	client, err := NewClient(WithServiceName("stats-test"), WithExporter(&recordingExporter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.LogInfo(context.Background(), "order placed")
+
+	handler := client.StatsHandler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/goinsight/stats", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var got Stats
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body %s: %v", rec.Body, err)
+	}
+	if got.Sent != 1 || got.CircuitState != CircuitClosed {
+		t.Errorf("served %+v, want one sent and a closed circuit", got)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/goinsight/stats", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("POST = %d, Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
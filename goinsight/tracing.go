@@ -2,85 +2,139 @@ package goinsight
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"time"
 )
 
-func (c *Client) StartTrace(ctx context.Context, operation string) (context.Context, *TraceContext, error) {
+func (c *Client) StartTrace(ctx context.Context, operation string, opts ...SpanOption) (context.Context, *TraceContext, error) {
+	if !c.sampler.ShouldSample(ctx, operation) {
+		traceCtx := &TraceContext{unsampled: true}
+		return context.WithValue(ctx, "go-insight-trace", traceCtx), traceCtx, nil
+	}
+
+	// Process the root span first: the trace is only created if it is kept
+	cfg := newSpanConfig(opts)
+	start := cfg.time()
+	span := Span{
+		Service:    c.serviceName,
+		Operation:  operation,
+		StartTime:  start,
+		Kind:       cfg.kind,
+		Links:      cfg.links,
+		Attributes: cfg.attributes,
+	}
+	if !c.processSpan(ctx, &span) {
+		traceCtx := &TraceContext{unsampled: true}
+		return context.WithValue(ctx, "go-insight-trace", traceCtx), traceCtx, nil
+	}
+
 	trace := Trace{
 		ServiceName: c.serviceName,
+		Resource:    c.resource,
 	}
 
-	resp, err := c.sendTrace(trace)
+	traceID, err := c.sendTrace(ctx, trace)
+	if errors.Is(err, ErrCircuitOpen) {
+		// Degrade to a local no-op trace rather than failing the caller
+		traceCtx := &TraceContext{unsampled: true}
+		return context.WithValue(ctx, "go-insight-trace", traceCtx), traceCtx, nil
+	}
 	if err != nil {
 		return ctx, nil, err
 	}
 
 	traceCtx := &TraceContext{
-		TraceID: resp["id"].(string),
+		TraceID: traceID,
+		start:   start,
 	}
 
 	// Start root span
-	span := Span{
-		TraceID:   traceCtx.TraceID,
-		Service:   c.serviceName,
-		Operation: operation,
-	}
-
-	spanResp, err := c.sendSpan(span)
+	span.TraceID = traceID
+	spanID, err := c.sendSpan(ctx, span)
 	if err != nil {
 		return ctx, traceCtx, err
 	}
 
-	traceCtx.SpanID = spanResp["id"].(string)
+	traceCtx.SpanID = spanID
 
 	newCtx := context.WithValue(ctx, "go-insight-trace", traceCtx)
 
 	return newCtx, traceCtx, nil
 }
 
-func (c *Client) StartSpan(ctx context.Context, operation string) (context.Context, error) {
+func (c *Client) StartSpan(ctx context.Context, operation string, opts ...SpanOption) (context.Context, error) {
 	traceCtx := GetTraceFromContext(ctx)
 	if traceCtx == nil {
-		return ctx, fmt.Errorf("no trace context found")
+		return ctx, ErrNoTraceContext
+	}
+	if traceCtx.unsampled {
+		// Children of an unsampled trace aren't recorded either
+		return ctx, nil
 	}
 
+	cfg := newSpanConfig(opts)
+	start := cfg.time()
+
 	span := Span{
-		TraceID:   traceCtx.TraceID,
-		ParentID:  traceCtx.SpanID,
-		Service:   c.serviceName,
-		Operation: operation,
+		TraceID:    traceCtx.TraceID,
+		ParentID:   traceCtx.SpanID,
+		Service:    c.serviceName,
+		Operation:  operation,
+		StartTime:  start,
+		Kind:       cfg.kind,
+		Links:      cfg.links,
+		Attributes: cfg.attributes,
+	}
+	if !c.processSpan(ctx, &span) {
+		// Treated like an unsampled trace, so descendants are dropped too
+		return context.WithValue(ctx, "go-insight-trace", &TraceContext{unsampled: true}), nil
 	}
 
-	resp, err := c.sendSpan(span)
+	spanID, err := c.sendSpan(ctx, span)
+	if errors.Is(err, ErrCircuitOpen) {
+		return context.WithValue(ctx, "go-insight-trace", &TraceContext{unsampled: true}), nil
+	}
 	if err != nil {
 		return ctx, err
 	}
 
 	newTraceCtx := &TraceContext{
 		TraceID: traceCtx.TraceID,
-		SpanID:  resp["id"].(string),
+		SpanID:  spanID,
+		start:   start,
 	}
 
 	newCtx := context.WithValue(ctx, "go-insight-trace", newTraceCtx)
 	return newCtx, nil
 }
 
-func (c *Client) FinishSpan(ctx context.Context) error {
+func (c *Client) FinishSpan(ctx context.Context, opts ...SpanOption) error {
 	traceCtx := GetTraceFromContext(ctx)
 	if traceCtx == nil {
-		return fmt.Errorf("no trace context found")
+		return ErrNoTraceContext
+	}
+
+	if traceCtx.unsampled {
+		return nil
 	}
 
-	return c.endSpan(traceCtx.SpanID)
+	cfg := newSpanConfig(opts)
+	end := cfg.spanEnd(traceCtx.start, cfg.time())
+	end.Attributes = c.redact.RedactMap(end.Attributes)
+	end.Error = c.redact.RedactString(end.Error)
+	return c.endSpan(ctx, traceCtx.SpanID, end)
 }
 
 func (c *Client) FinishTrace(ctx context.Context) error {
 	traceCtx := GetTraceFromContext(ctx)
 	if traceCtx == nil {
-		return fmt.Errorf("no trace context found")
+		return ErrNoTraceContext
+	}
+	if traceCtx.unsampled {
+		return nil
 	}
 
-	return c.endTrace(traceCtx.TraceID)
+	return c.endTrace(ctx, traceCtx.TraceID)
 }
 
 func GetTraceFromContext(ctx context.Context) *TraceContext {
@@ -89,3 +143,99 @@ func GetTraceFromContext(ctx context.Context) *TraceContext {
 	}
 	return nil
 }
+
+// SpanOption configures a span when it is started or finished
+type SpanOption func(*spanConfig)
+
+type spanConfig struct {
+	attributes map[string]interface{}
+	kind       SpanKind
+	links      []SpanLink
+	timestamp  time.Time
+	err        error
+}
+
+func newSpanConfig(opts []SpanOption) *spanConfig {
+	cfg := &spanConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithAttributes records attrs on the span. Passed to StartSpan they describe
+// the operation up front; passed to FinishSpan they record results such as
+// row counts. Repeated options are merged.
+func WithAttributes(attrs map[string]interface{}) SpanOption {
+	return func(cfg *spanConfig) {
+		if len(attrs) == 0 {
+			return
+		}
+		if cfg.attributes == nil {
+			cfg.attributes = make(map[string]interface{}, len(attrs))
+		}
+		for k, v := range attrs {
+			cfg.attributes[k] = v
+		}
+	}
+}
+
+// WithSpanKind sets the kind of a span passed to StartSpan or StartTrace
+func WithSpanKind(kind SpanKind) SpanOption {
+	return func(cfg *spanConfig) {
+		cfg.kind = kind
+	}
+}
+
+// WithLinks links a span passed to StartSpan or StartTrace to other spans.
+// Repeated options are appended, so a batch consumer can link every message
+// it handles.
+func WithLinks(links ...SpanLink) SpanOption {
+	return func(cfg *spanConfig) {
+		cfg.links = append(cfg.links, links...)
+	}
+}
+
+// WithTimestamp backdates a span: passed to StartSpan or StartTrace it sets
+// the start time, passed to FinishSpan the end time. Use it to record work
+// after the fact; by default the current time is used.
+func WithTimestamp(t time.Time) SpanOption {
+	return func(cfg *spanConfig) {
+		cfg.timestamp = t
+	}
+}
+
+// time returns the option's timestamp, or the current time
+func (cfg *spanConfig) time() time.Time {
+	if cfg.timestamp.IsZero() {
+		return time.Now()
+	}
+	return cfg.timestamp
+}
+
+// WithSpanError marks the span as failed with err when passed to FinishSpan.
+// A nil err leaves the span successful.
+func WithSpanError(err error) SpanOption {
+	return func(cfg *spanConfig) {
+		cfg.err = err
+	}
+}
+
+// spanEnd builds the payload sent when a span that started at start ends at
+// end. The duration uses the monotonic clock unless either time was
+// backdated; it is zero when the start isn't known.
+func (cfg *spanConfig) spanEnd(start, end time.Time) SpanEnd {
+	spanEnd := SpanEnd{
+		EndTime:    end,
+		Status:     SpanStatusOK,
+		Attributes: cfg.attributes,
+	}
+	if !start.IsZero() {
+		spanEnd.Duration = float64(end.Sub(start).Nanoseconds()) / 1e6
+	}
+	if cfg.err != nil {
+		spanEnd.Status = SpanStatusError
+		spanEnd.Error = cfg.err.Error()
+	}
+	return spanEnd
+}
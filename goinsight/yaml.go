@@ -0,0 +1,308 @@
+package goinsight
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML parses the subset of YAML config files need: nested block
+// mappings, block sequences of scalars, flow sequences and mappings of
+// scalars, quoted and plain scalars, and comments. Anchors, multi-line
+// scalars and multiple documents are not supported, which keeps the SDK free
+// of a YAML dependency.
+func parseYAML(data []byte) (map[string]interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		text := strings.TrimRight(stripComment(raw), " \t\r")
+		trimmed := strings.TrimLeft(text, " \t")
+		if trimmed == "" || (len(lines) == 0 && trimmed == "---") {
+			continue
+		}
+		if strings.Contains(text[:len(text)-len(trimmed)], "\t") {
+			return nil, fmt.Errorf("yaml: line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+
+	p := &yamlParser{lines: lines}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	if lines[0].indent != 0 {
+		return nil, p.errorf(lines[0], "document must start at column 1")
+	}
+	doc, err := p.mapping(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, p.errorf(lines[p.pos], "unexpected indentation")
+	}
+	return doc, nil
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) errorf(line yamlLine, format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", line.num, fmt.Sprintf(format, args...))
+}
+
+// mapping parses consecutive "key: value" lines at indent
+func (p *yamlParser) mapping(indent int) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf(line, "unexpected indentation")
+		}
+		if strings.HasPrefix(line.text, "- ") || line.text == "-" {
+			return nil, p.errorf(line, "expected a key, found a sequence item")
+		}
+
+		key, rest, ok := splitKey(line.text)
+		if !ok {
+			return nil, p.errorf(line, "expected \"key: value\"")
+		}
+		if _, dup := m[key]; dup {
+			return nil, p.errorf(line, "duplicate key %q", key)
+		}
+		p.pos++
+
+		if rest != "" {
+			v, err := parseFlow(rest)
+			if err != nil {
+				return nil, p.errorf(line, "%v", err)
+			}
+			m[key] = v
+			continue
+		}
+
+		// A nested block, or null when nothing is indented below the key
+		if p.pos >= len(p.lines) || p.lines[p.pos].indent < indent ||
+			(p.lines[p.pos].indent == indent && !strings.HasPrefix(p.lines[p.pos].text, "-")) {
+			m[key] = nil
+			continue
+		}
+		child := p.lines[p.pos]
+		var err error
+		if strings.HasPrefix(child.text, "- ") || child.text == "-" {
+			m[key], err = p.sequence(child.indent)
+		} else if child.indent > indent {
+			m[key], err = p.mapping(child.indent)
+		} else {
+			err = p.errorf(child, "unexpected indentation")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// sequence parses consecutive "- value" lines at indent
+func (p *yamlParser) sequence(indent int) ([]interface{}, error) {
+	var items []interface{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !(strings.HasPrefix(line.text, "- ") || line.text == "-") {
+			break
+		}
+		item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if _, _, isMap := splitKey(item); isMap && !isQuoted(item) {
+			return nil, p.errorf(line, "mappings inside sequences are not supported")
+		}
+		v, err := parseFlow(item)
+		if err != nil {
+			return nil, p.errorf(line, "%v", err)
+		}
+		items = append(items, v)
+		p.pos++
+	}
+	return items, nil
+}
+
+// splitKey splits "key: rest", unquoting the key
+func splitKey(text string) (key, rest string, ok bool) {
+	if isQuoted(text) {
+		end := closingQuote(text)
+		if end < 0 || !strings.HasPrefix(text[end+1:], ":") {
+			return "", "", false
+		}
+		k, err := parseScalar(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		return fmt.Sprint(k), strings.TrimSpace(text[end+2:]), true
+	}
+
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		i = len(text) - 1
+	}
+	key = strings.TrimSpace(text[:i])
+	if key == "" || strings.ContainsAny(key[:1], "[{") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(text[i+1:]), true
+}
+
+// parseFlow parses a scalar or a flow sequence or mapping of scalars
+func parseFlow(text string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("unterminated flow sequence %q", text)
+		}
+		items := []interface{}{}
+		for _, part := range splitFlow(text[1 : len(text)-1]) {
+			v, err := parseScalar(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case strings.HasPrefix(text, "{"):
+		if !strings.HasSuffix(text, "}") {
+			return nil, fmt.Errorf("unterminated flow mapping %q", text)
+		}
+		m := map[string]interface{}{}
+		for _, part := range splitFlow(text[1 : len(text)-1]) {
+			k, rest, ok := splitKey(part)
+			if !ok {
+				return nil, fmt.Errorf("expected \"key: value\" in flow mapping, found %q", part)
+			}
+			v, err := parseScalar(rest)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	}
+	return parseScalar(text)
+}
+
+// splitFlow splits the body of a flow collection on commas outside quotes
+func splitFlow(body string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && startsValue(body[:i]):
+			quote = c
+		case c == ',':
+			parts = append(parts, strings.TrimSpace(body[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(body[start:]); last != "" || len(parts) > 0 {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// parseScalar converts a plain or quoted scalar to a string, bool, nil or
+// json.Number. Numbers keep their text, so 1.10 isn't read back as 1.1 and
+// fields that take a string get the digits as written.
+func parseScalar(text string) (interface{}, error) {
+	if isQuoted(text) {
+		if closingQuote(text) != len(text)-1 {
+			return nil, fmt.Errorf("malformed quoted string %s", text)
+		}
+		if text[0] == '"' {
+			var s string
+			if err := json.Unmarshal([]byte(text), &s); err != nil {
+				return nil, fmt.Errorf("malformed quoted string %s", text)
+			}
+			return s, nil
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil && json.Valid([]byte(text)) {
+		return json.Number(text), nil
+	}
+	return text, nil
+}
+
+func isQuoted(text string) bool {
+	return len(text) > 0 && (text[0] == '"' || text[0] == '\'')
+}
+
+// closingQuote returns the index of the quote closing the string text starts
+// with, or -1
+func closingQuote(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment removes a trailing "#" comment outside quotes
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && startsValue(line[:i]):
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// startsValue reports whether a quote following before opens a quoted
+// scalar rather than being part of a plain one, as in "don't"
+func startsValue(before string) bool {
+	before = strings.TrimRight(before, " ")
+	return before == "" || strings.ContainsAny(before[len(before)-1:], ":-[{,")
+}